@@ -1,109 +1,408 @@
-package perspective_taker
+package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+
+	"perspective_taker/config"
+	"perspective_taker/crisis"
+	"perspective_taker/safety"
+	"perspective_taker/sanitize"
+
+	"github.com/spf13/cobra"
 )
 
-func main() {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Println("Perspective Taker CLI")
-	fmt.Println("Type 'help' for available commands or 'exit' to quit")
+var (
+	cfg   config.Config
+	stdin = bufio.NewReader(os.Stdin)
 
-	for {
-		fmt.Print("Enter command: ")
-		input, err := reader.ReadString('\n')
-		if err != nil {
-			fmt.Println("Failed to read input:", err)
-			continue
-		}
+	// configPath is the config file in use, where commands such as safety
+	// save the settings they change.
+	configPath string
 
-		input = strings.TrimSpace(input)
-		args := strings.Split(input, " ")
+	inputSanitizer = sanitize.New(sanitize.Flag)
+	outputFilter   = safety.NewFilter(log.New(&auditLog{}, "", log.LstdFlags))
 
-		switch args[0] {
-		case "exit":
-			fmt.Println("Exiting CLI...")
-			return
-		case "help":
-			displayHelp()
-		case "list":
-			listPerspectives()
-		case "select":
-			if len(args) < 3 {
-				fmt.Println("Not enough arguments for select. Usage: select [perspective] [beliefMode]")
-				continue
+	crisisDetector crisis.Detector = crisis.NewKeywordDetector()
+	crisisRegion                   = crisis.RegionFromEnv()
+)
+
+func main() {
+	defer recoverCrash()
+	cmd, err := newRootCmd().ExecuteC()
+	recordUsage(cmd, err)
+	if err != nil {
+		reportError(err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "perspective-taker",
+		Short:         "Investigate a topic through the lens of selected perspectives",
+		Version:       version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          usageArgs(cobra.NoArgs),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := setupLogging(); err != nil {
+				return err
+			}
+			loaded, err := config.Load(cmd.Flags())
+			if err != nil {
+				err = fmt.Errorf("loading config: %w", err)
+				if errors.As(err, new(*config.ValueError)) {
+					return usageError{err}
+				}
+				return err
 			}
-			selectPerspective(args[1], args[2])
-		case "dialogue":
-			startDialogue()
-			manageDialogue()
-		case "summary":
-			showSummary()
-		default:
-			fmt.Println("Unknown command:", args[0])
+			cfg = loaded
+			configPath = config.Path(cmd.Flags())
+			for _, e := range cfg.Entries() {
+				logger.Debug("config", "option", e[0], "value", e[1])
+			}
+			logger.Debug("running command", "command", cmd.CommandPath())
+			return applyConfig(cfg)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInteractive()
+		},
+	}
+	config.Flags(root.PersistentFlags())
+	addOutputFlags(root)
+	addLoggingFlags(root)
+
+	addSessionCommands(root)
+	root.AddCommand(updateCmd(), telemetryCmd())
+	root.AddCommand(&cobra.Command{
+		Use:   "interactive",
+		Short: "Start an interactive session (the default when no command is given)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInteractive()
+		},
+	})
+	return root
+}
+
+// addSessionCommands registers the commands that are available both from the
+// shell and inside an interactive session.
+func addSessionCommands(parent *cobra.Command) {
+	parent.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List available perspectives",
+			Args:  usageArgs(cobra.NoArgs),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return listPerspectives()
+			},
+		},
+		&cobra.Command{
+			Use:   "select <perspective> <beliefMode>",
+			Short: "Select a perspective and belief mode",
+			Args:  usageArgs(cobra.ExactArgs(2)),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return selectPerspective(args[0], args[1])
+			},
+		},
+		dialogueCmd(),
+		sessionsCmd(),
+		resumeCmd(),
+		exportCmd(),
+		diffSessionsCmd(),
+		searchCmd(),
+		storeCmd(),
+		scenarioCmd(),
+		simulateCmd(),
+		&cobra.Command{
+			Use:   "summary",
+			Short: "Show summary of updated beliefs",
+			Args:  usageArgs(cobra.NoArgs),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return showSummary()
+			},
+		},
+		&cobra.Command{
+			Use:   "config",
+			Short: "Show the effective configuration",
+			Args:  usageArgs(cobra.NoArgs),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return showConfig()
+			},
+		},
+		&cobra.Command{
+			Use:       "sanitize [flag|strip|block]",
+			Short:     "Show or set (and save) how suspected prompt injections in responses are handled",
+			Args:      usageArgs(cobra.MaximumNArgs(1)),
+			ValidArgs: []string{"flag", "strip", "block"},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if len(args) == 0 {
+					return showInputPolicy()
+				}
+				return setInputPolicy(args[0])
+			},
+		},
+		&cobra.Command{
+			Use:   "safety [policy off|annotate|block]",
+			Short: "Show or set (and save) content safety policies for generated text",
+			Args: func(cmd *cobra.Command, args []string) error {
+				if len(args) != 0 && len(args) != 2 {
+					return usageError{fmt.Errorf("accepts 0 or 2 arg(s), received %d", len(args))}
+				}
+				return nil
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if len(args) == 0 {
+					return showSafetyPolicies()
+				}
+				return setSafetyMode(args[0], args[1])
+			},
+		},
+		&cobra.Command{
+			Use:       "crisis on|off|region CODE",
+			Short:     "Toggle (and save) crisis-language detection or set the region for support resources",
+			Args:      usageArgs(cobra.RangeArgs(1, 2)),
+			ValidArgs: []string{"on", "off", "region"},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return configureCrisis(args)
+			},
+		},
+	)
+}
+
+func applyConfig(c config.Config) error {
+	policy, err := sanitize.ParsePolicy(c.SanitizePolicy)
+	if err != nil {
+		return err
+	}
+	inputSanitizer.Policy = policy
+
+	if err := outputFilter.SetModes(c.SafetyPolicies); err != nil {
+		return err
+	}
+
+	if !c.CrisisDetection {
+		crisisDetector = nil
+	}
+	if c.CrisisRegion != "" {
+		crisisRegion = c.CrisisRegion
+	}
+	return nil
+}
+
+func showConfig() error {
+	if jsonOutput {
+		values := map[string]string{}
+		for _, e := range cfg.Entries() {
+			values[e[0]] = e[1]
 		}
+		return printJSON(values)
+	}
+	for _, e := range cfg.Entries() {
+		fmt.Printf("%s = %s\n", e[0], e[1])
 	}
+	return nil
 }
 
-func displayHelp() {
-	helpText := `
-Available commands:
-exit - Exit the CLI
-help - Show this help message
-list - List available perspectives
-select [perspective] [beliefMode] - Select a perspective and belief mode
-dialogue - Start and manage a dialogue
-summary - Show summary of updated beliefs
-`
-	fmt.Println(helpText)
+type perspectiveList struct {
+	Perspectives []string `json:"perspectives"`
 }
 
-func listPerspectives() {
+func listPerspectives() error {
+	if jsonOutput {
+		return printJSON(perspectiveList{Perspectives: []string{}})
+	}
 	fmt.Println("Listing available perspectives")
+	return nil
+}
+
+type selection struct {
+	Perspective string `json:"perspective"`
+	BeliefMode  string `json:"belief_mode"`
 }
 
-func selectPerspective(perspective, beliefMode string) {
+func selectPerspective(perspective, beliefMode string) error {
+	if jsonOutput {
+		return printJSON(selection{Perspective: perspective, BeliefMode: beliefMode})
+	}
 	fmt.Printf("Selected perspective: %s with belief mode: %s\n", perspective, beliefMode)
+	return nil
 }
 
-func startDialogue() {
-	fmt.Println("Starting dialogue...")
-	startDialectic()
+type inputPolicy struct {
+	Policy string `json:"policy"`
 }
 
-func manageDialogue() {
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		fmt.Print("Enter your response (type 'end' to finish dialogue): ")
-		response, err := reader.ReadString('\n')
-		if err != nil {
-			fmt.Println("Failed to read input:", err)
-			continue
+func showInputPolicy() error {
+	if jsonOutput {
+		return printJSON(inputPolicy{Policy: inputSanitizer.Policy.String()})
+	}
+	fmt.Println("Current input policy:", inputSanitizer.Policy)
+	return nil
+}
+
+func setInputPolicy(name string) error {
+	policy, err := sanitize.ParsePolicy(name)
+	if err != nil {
+		return usageError{err}
+	}
+	inputSanitizer.Policy = policy
+	if err := saveOption("sanitize_policy", policy.String()); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(inputPolicy{Policy: policy.String()})
+	}
+	fmt.Println("Input policy set to:", policy)
+	return nil
+}
+
+type safetyPolicy struct {
+	Name string `json:"name"`
+	Mode string `json:"mode"`
+}
+
+type safetyPolicies struct {
+	Policies []safetyPolicy `json:"policies"`
+}
+
+func showSafetyPolicies() error {
+	if jsonOutput {
+		result := safetyPolicies{Policies: []safetyPolicy{}}
+		for _, p := range outputFilter.Policies {
+			result.Policies = append(result.Policies, safetyPolicy{Name: p.Name, Mode: p.Mode.String()})
 		}
+		return printJSON(result)
+	}
+	for _, p := range outputFilter.Policies {
+		fmt.Printf("%s: %s\n", p.Name, p.Mode)
+	}
+	return nil
+}
 
-		response = strings.TrimSpace(response)
-		if response == "end" {
-			fmt.Println("Ending dialogue...")
-			break
+func setSafetyMode(name, modeName string) error {
+	policy := outputFilter.Policy(name)
+	if policy == nil {
+		return usageError{fmt.Errorf("unknown safety policy %q", name)}
+	}
+	mode, err := safety.ParseMode(modeName)
+	if err != nil {
+		return usageError{err}
+	}
+	policy.Mode = mode
+	if err := saveOption("safety_policies", outputFilter.Modes()); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(safetyPolicy{Name: policy.Name, Mode: mode.String()})
+	}
+	fmt.Printf("Safety policy %s set to: %s\n", policy.Name, mode)
+	return nil
+}
+
+// saveOption records a setting changed by a command in the config file, so
+// that it outlasts this process, and in the effective config. Commands such
+// as crisis off run from the shell as well, where an in-memory change would
+// be lost on exit.
+func saveOption(name, value string) error {
+	if err := config.Save(configPath, name, value); err != nil {
+		return fmt.Errorf("saving %s: %w", name, err)
+	}
+	return cfg.Set(name, value)
+}
+
+func writeCrisisResources(out io.Writer) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "It sounds like you might be going through something really hard. You don't have to face it alone.")
+	fmt.Fprintln(out, "You can reach out for support right now:")
+	for _, r := range crisis.Resources(crisisRegion) {
+		fmt.Fprintf(out, "  %s: %s\n", r.Name, r.Contact)
+	}
+	fmt.Fprintln(out)
+}
+
+type crisisSettings struct {
+	Enabled bool   `json:"enabled"`
+	Region  string `json:"region"`
+}
+
+func configureCrisis(args []string) error {
+	var err error
+	switch args[0] {
+	case "on":
+		crisisDetector = crisis.NewKeywordDetector()
+		err = saveOption("crisis_detection", "true")
+	case "off":
+		crisisDetector = nil
+		err = saveOption("crisis_detection", "false")
+	case "region":
+		if len(args) < 2 {
+			return usageError{fmt.Errorf("usage: crisis region CODE (e.g. US, GB, AU)")}
 		}
+		crisisRegion = strings.ToUpper(args[1])
+		err = saveOption("crisis_region", crisisRegion)
+	default:
+		return usageError{fmt.Errorf("unknown crisis option %q", args[0])}
+	}
+	if err != nil {
+		return err
+	}
 
-		updateDialectic(response)
+	if jsonOutput {
+		return printJSON(crisisSettings{Enabled: crisisDetector != nil, Region: crisisRegion})
+	}
+	switch args[0] {
+	case "on":
+		fmt.Println("Crisis-language detection enabled")
+	case "off":
+		fmt.Println("Crisis-language detection disabled")
+	case "region":
+		fmt.Println("Support resources region set to:", crisisRegion)
 	}
+	return nil
 }
 
-func startDialectic() {
-	fmt.Println("Dialectic session started. Here's your first question:")
-	fmt.Println("What are your initial thoughts on the concept of personal identity?")
+// auditLog appends to safety-audit.log in the config directory. The file is
+// only created on the first write, so runs where no safety policy triggers
+// leave nothing behind. If it can't be opened, audit entries are dropped.
+type auditLog struct {
+	once sync.Once
+	f    *os.File
 }
 
-func updateDialectic(response string) {
-	fmt.Printf("You answered: %s\n", response)
-	fmt.Println("How does this relate to the continuity or change over time?")
+func (a *auditLog) Write(p []byte) (int, error) {
+	a.once.Do(func() {
+		dir, err := config.Dir()
+		if err != nil {
+			return
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return
+		}
+		a.f, _ = os.OpenFile(filepath.Join(dir, "safety-audit.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	})
+	if a.f == nil {
+		return len(p), nil
+	}
+	return a.f.Write(p)
+}
+
+type beliefSummary struct {
+	Beliefs []string `json:"beliefs"`
 }
 
-func showSummary() {
+func showSummary() error {
+	if jsonOutput {
+		return printJSON(beliefSummary{Beliefs: []string{}})
+	}
 	fmt.Println("Summary of updated beliefs:")
+	return nil
 }
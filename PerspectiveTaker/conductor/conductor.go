@@ -0,0 +1,413 @@
+package conductor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Action is what a session does next.
+type Action int
+
+const (
+	AskServer Action = iota
+	AskLocal
+	Summarize
+	End
+	// UserAsk is a turn where the user asks the agent a question. The turn's
+	// Question is the user's question and its Answer is the agent's reply.
+	UserAsk
+)
+
+func (a Action) String() string {
+	switch a {
+	case AskServer:
+		return "server"
+	case AskLocal:
+		return "local"
+	case Summarize:
+		return "summary"
+	case End:
+		return "end"
+	case UserAsk:
+		return "user"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+func ParseAction(s string) (Action, error) {
+	for a := AskServer; a <= UserAsk; a++ {
+		if a.String() == s {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown action %q", s)
+}
+
+type Turn struct {
+	Action   Action
+	Question string
+	Answer   string
+	Time     time.Time
+	// Perspective is the one the user had taken when answering or asking,
+	// if any.
+	Perspective string
+}
+
+func (t Turn) isQuestion() bool {
+	return t.Action == AskServer || t.Action == AskLocal
+}
+
+type State struct {
+	Turns []Turn
+}
+
+// Asked counts the question turns so far, answered or not.
+func (s State) Asked() int {
+	n := 0
+	for _, t := range s.Turns {
+		if t.isQuestion() {
+			n++
+		}
+	}
+	return n
+}
+
+// Answered counts the questions the user has answered.
+func (s State) Answered() int {
+	n := 0
+	for _, t := range s.Turns {
+		if t.isQuestion() && t.Answer != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// pending returns the index of the question awaiting an answer, or -1.
+func (s State) pending() int {
+	for i := len(s.Turns) - 1; i >= 0; i-- {
+		if t := s.Turns[i]; t.isQuestion() {
+			if t.Answer == "" {
+				return i
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// summarized reports whether a summary was given after the latest question.
+func (s State) summarized() bool {
+	for i := len(s.Turns) - 1; i >= 0; i-- {
+		switch s.Turns[i].Action {
+		case Summarize:
+			return true
+		case AskServer, AskLocal:
+			return false
+		}
+	}
+	return false
+}
+
+// Conductor decides the next action of a session from its state so far.
+type Conductor interface {
+	Next(ctx context.Context, s State) (Action, error)
+}
+
+// QuestionSource produces questions, e.g. the EpistemicMe backend or a local LLM.
+type QuestionSource interface {
+	Question(ctx context.Context, s State) (string, error)
+}
+
+type Summarizer interface {
+	Summarize(ctx context.Context, s State) (string, error)
+}
+
+// Responder answers questions the user asks the agent mid-session.
+type Responder interface {
+	Respond(ctx context.Context, s State, question string) (string, error)
+}
+
+// Session runs the conductor's decisions against the configured sources.
+// Local, Summarizer and Responder are optional; without them AskLocal falls
+// back to the server, Summarize is skipped and users cannot ask questions.
+type Session struct {
+	Conductor  Conductor
+	Server     QuestionSource
+	Local      QuestionSource
+	Summarizer Summarizer
+	Responder  Responder
+	State      State
+	// Perspective is the one the user is currently taking. It is recorded
+	// on every turn they answer or ask.
+	Perspective string
+}
+
+var ErrUnanswered = errors.New("conductor: previous question has not been answered")
+
+// Next carries out the next action and returns the resulting turn. Question
+// turns should be answered with Answer before calling Next again; summary
+// turns need no answer. A turn with Action End means the session is over.
+func (s *Session) Next(ctx context.Context) (Turn, error) {
+	if s.State.pending() >= 0 {
+		return Turn{}, ErrUnanswered
+	}
+
+	action, err := s.Conductor.Next(ctx, s.State)
+	if err != nil {
+		return Turn{}, err
+	}
+	if action == AskLocal && s.Local == nil {
+		action = AskServer
+	}
+
+	var text string
+	switch action {
+	case AskServer:
+		text, err = s.Server.Question(ctx, s.State)
+	case AskLocal:
+		text, err = s.Local.Question(ctx, s.State)
+	case Summarize:
+		if s.Summarizer == nil {
+			return s.skipSummary(ctx)
+		}
+		text, err = s.Summarizer.Summarize(ctx, s.State)
+	case End:
+		return Turn{Action: End}, nil
+	default:
+		return Turn{}, fmt.Errorf("conductor: unknown action %v", action)
+	}
+	if err != nil {
+		return Turn{}, fmt.Errorf("conductor: %v: %w", action, err)
+	}
+
+	turn := Turn{Action: action, Question: text, Time: time.Now()}
+	s.State.Turns = append(s.State.Turns, turn)
+	return turn, nil
+}
+
+// skipSummary records an empty summary turn so the conductor sees that it
+// already asked for one, then moves on.
+func (s *Session) skipSummary(ctx context.Context) (Turn, error) {
+	s.State.Turns = append(s.State.Turns, Turn{Action: Summarize, Time: time.Now()})
+	return s.Next(ctx)
+}
+
+// Pending returns the question awaiting an answer, if any. A resumed
+// session may start with one.
+func (s *Session) Pending() (Turn, bool) {
+	i := s.State.pending()
+	if i < 0 {
+		return Turn{}, false
+	}
+	return s.State.Turns[i], true
+}
+
+// Answer records the user's answer to the pending question.
+func (s *Session) Answer(answer string) error {
+	i := s.State.pending()
+	if i < 0 {
+		return errors.New("conductor: no question to answer")
+	}
+	s.State.Turns[i].Answer = answer
+	s.State.Turns[i].Perspective = s.Perspective
+	return nil
+}
+
+// Ask lets the user flip roles and put a question to the agent. It can be
+// called while a question is pending; that question stays open.
+func (s *Session) Ask(ctx context.Context, question string) (Turn, error) {
+	if s.Responder == nil {
+		return Turn{}, errors.New("conductor: this session does not take questions")
+	}
+	reply, err := s.Responder.Respond(ctx, s.State, question)
+	if err != nil {
+		return Turn{}, fmt.Errorf("conductor: %v: %w", UserAsk, err)
+	}
+	turn := Turn{Action: UserAsk, Question: question, Answer: reply, Time: time.Now(), Perspective: s.Perspective}
+	s.State.Turns = append(s.State.Turns, turn)
+	return turn, nil
+}
+
+// ServerOnly asks the backend for every question.
+type ServerOnly struct {
+	MaxTurns int
+}
+
+func (c ServerOnly) Next(ctx context.Context, s State) (Action, error) {
+	if c.MaxTurns > 0 && s.Answered() >= c.MaxTurns {
+		return End, nil
+	}
+	return AskServer, nil
+}
+
+// Hybrid mixes backend and locally generated questions and periodically
+// summarizes. Zero values disable the corresponding behavior.
+type Hybrid struct {
+	LocalEvery     int
+	SummarizeEvery int
+	MaxTurns       int
+}
+
+func (c Hybrid) Next(ctx context.Context, s State) (Action, error) {
+	answered := s.Answered()
+	if c.MaxTurns > 0 && answered >= c.MaxTurns {
+		return End, nil
+	}
+	if c.SummarizeEvery > 0 && answered > 0 && answered%c.SummarizeEvery == 0 && !s.summarized() {
+		return Summarize, nil
+	}
+	if c.LocalEvery > 0 && (s.Asked()+1)%c.LocalEvery == 0 {
+		return AskLocal, nil
+	}
+	return AskServer, nil
+}
+
+// Script serves a fixed list of questions in order and repeats the last one
+// once the list is exhausted. Served counts the questions handed out so far;
+// set it when resuming a session.
+type Script struct {
+	Questions []string
+	Served    int
+}
+
+func (s *Script) Question(ctx context.Context, st State) (string, error) {
+	if len(s.Questions) == 0 {
+		return "", errors.New("script has no questions")
+	}
+	q := s.Questions[min(s.Served, len(s.Questions)-1)]
+	s.Served++
+	return q, nil
+}
+
+// FollowUp generates a question locally by asking the user to unpack their
+// previous answer. It needs no model, so it works offline.
+type FollowUp struct{}
+
+func (FollowUp) Question(ctx context.Context, s State) (string, error) {
+	for i := len(s.Turns) - 1; i >= 0; i-- {
+		if a := s.Turns[i].Answer; s.Turns[i].isQuestion() && a != "" {
+			return fmt.Sprintf("You said %q. What experience or reasoning led you to that view?", a), nil
+		}
+	}
+	return "What first comes to mind when you think about this topic?", nil
+}
+
+// Novel guards a question source against asking the same thing twice. A
+// question whose Similarity to an earlier question in the session reaches
+// Threshold is regenerated up to Retries times; if the source keeps
+// repeating itself, the question comes from Fallback instead, when set.
+type Novel struct {
+	Source    QuestionSource
+	Fallback  QuestionSource
+	Threshold float64
+	Retries   int
+}
+
+func (n Novel) Question(ctx context.Context, s State) (string, error) {
+	q, err := n.Source.Question(ctx, s)
+	if err != nil || n.Threshold <= 0 {
+		return q, err
+	}
+	for i := 0; i < n.Retries && n.repeats(s, q); i++ {
+		if q, err = n.Source.Question(ctx, s); err != nil {
+			return "", err
+		}
+	}
+	if n.Fallback != nil && n.repeats(s, q) {
+		return n.Fallback.Question(ctx, s)
+	}
+	return q, nil
+}
+
+func (n Novel) repeats(s State, q string) bool {
+	for _, t := range s.Turns {
+		if t.isQuestion() && Similarity(t.Question, q) >= n.Threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// Similarity is the share of content words two texts have in common, from 0
+// for nothing in common to 1 for the same words.
+func Similarity(a, b string) float64 {
+	wa, wb := ContentWords(a), ContentWords(b)
+	if len(wa) == 0 && len(wb) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wa)+len(wb)-shared)
+}
+
+// Recap summarizes by listing the user's answers so far.
+type Recap struct{}
+
+func (Recap) Summarize(ctx context.Context, s State) (string, error) {
+	var b strings.Builder
+	b.WriteString("So far you have said:")
+	for _, t := range s.Turns {
+		if t.isQuestion() && t.Answer != "" {
+			b.WriteString("\n  - ")
+			b.WriteString(t.Answer)
+		}
+	}
+	return b.String(), nil
+}
+
+// Reflect answers the user's questions from what they have said so far in
+// the session, quoting the answers that share the most words with the question.
+type Reflect struct{}
+
+func (Reflect) Respond(ctx context.Context, s State, question string) (string, error) {
+	words := ContentWords(question)
+	best, bestScore := Turn{}, 0
+	for _, t := range s.Turns {
+		if !t.isQuestion() || t.Answer == "" {
+			continue
+		}
+		score := 0
+		for w := range ContentWords(t.Question + " " + t.Answer) {
+			if words[w] {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+	if bestScore == 0 {
+		return "You haven't said anything about that yet in this session, so I can't tell what you believe.", nil
+	}
+	return fmt.Sprintf("When asked %q you answered %q, so I think that is close to what you believe.", best.Question, best.Answer), nil
+}
+
+var stopWords = map[string]bool{
+	"a": true, "about": true, "an": true, "and": true, "are": true, "believe": true, "do": true,
+	"does": true, "for": true, "how": true, "i": true, "in": true, "is": true, "it": true,
+	"me": true, "my": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"think": true, "this": true, "to": true, "what": true, "you": true, "your": true,
+}
+
+// ContentWords returns the lower-cased words of text that carry meaning,
+// leaving out short words and common stop words.
+func ContentWords(text string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len(w) > 2 && !stopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
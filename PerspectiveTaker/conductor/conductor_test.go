@@ -0,0 +1,125 @@
+package conductor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// fixed answers every request with the next of its questions, repeating the
+// last, and counts the requests.
+type fixed struct {
+	questions []string
+	calls     int
+}
+
+func (f *fixed) Question(ctx context.Context, s State) (string, error) {
+	q := f.questions[min(f.calls, len(f.questions)-1)]
+	f.calls++
+	return q, nil
+}
+
+func TestHybridSchedule(t *testing.T) {
+	session := &Session{
+		Conductor:  Hybrid{LocalEvery: 3, SummarizeEvery: 5, MaxTurns: 7},
+		Server:     &fixed{questions: []string{"server"}},
+		Local:      &fixed{questions: []string{"local"}},
+		Summarizer: Recap{},
+	}
+	var got []Action
+	for {
+		turn, err := session.Next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, turn.Action)
+		if turn.Action == End {
+			break
+		}
+		if turn.Action != Summarize {
+			if err := session.Answer("an answer"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	want := []Action{AskServer, AskServer, AskLocal, AskServer, AskServer, Summarize, AskLocal, AskServer, End}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %v, want %v", got, want)
+	}
+}
+
+func TestHybridWithoutLocalOrSummarizer(t *testing.T) {
+	session := &Session{
+		Conductor: Hybrid{LocalEvery: 2, SummarizeEvery: 1, MaxTurns: 3},
+		Server:    &fixed{questions: []string{"server"}},
+	}
+	for i := 0; i < 3; i++ {
+		turn, err := session.Next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if turn.Action != AskServer {
+			t.Fatalf("turn %d: %v, want %v", i, turn.Action, AskServer)
+		}
+		session.Answer("an answer")
+	}
+	if _, err := session.Next(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNextRequiresAnswer(t *testing.T) {
+	session := &Session{Conductor: ServerOnly{}, Server: &fixed{questions: []string{"Q?"}}}
+	if _, err := session.Next(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Next(context.Background()); err != ErrUnanswered {
+		t.Errorf("got %v, want ErrUnanswered", err)
+	}
+}
+
+func TestNovel(t *testing.T) {
+	asked := State{Turns: []Turn{{Action: AskServer, Question: "What is personal identity?", Answer: "memory"}}}
+	tests := []struct {
+		name      string
+		source    []string
+		fallback  QuestionSource
+		threshold float64
+		want      string
+		calls     int
+	}{
+		{"new question", []string{"Does identity change over time?"}, nil, 0.8, "Does identity change over time?", 1},
+		{"retried", []string{"What is personal identity?", "Does identity change over time?"}, nil, 0.8, "Does identity change over time?", 2},
+		{"falls back", []string{"What is personal identity?"}, FollowUp{}, 0.8, `You said "memory". What experience or reasoning led you to that view?`, 3},
+		{"no fallback", []string{"What is personal identity?"}, nil, 0.8, "What is personal identity?", 3},
+		{"reworded repeat", []string{"What, then, is personal identity?"}, FollowUp{}, 0.6, `You said "memory". What experience or reasoning led you to that view?`, 3},
+		{"guard off", []string{"What is personal identity?"}, FollowUp{}, 0, "What is personal identity?", 1},
+	}
+	for _, tt := range tests {
+		source := &fixed{questions: tt.source}
+		n := Novel{Source: source, Fallback: tt.fallback, Threshold: tt.threshold, Retries: 2}
+		got, err := n.Question(context.Background(), asked)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || source.calls != tt.calls {
+			t.Errorf("%s: got %q after %d calls, want %q after %d", tt.name, got, source.calls, tt.want, tt.calls)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"memory shapes identity", "Identity shapes memory!", 1},
+		{"memory shapes identity", "memory matters", 0.25},
+		{"memory", "body", 0},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const EnvPrefix = "EPISTEMICME_"
+
+type Config struct {
+	BaseURL         string  `json:"base_url"`
+	APIKey          string  `json:"api_key"`
+	SanitizePolicy  string  `json:"sanitize_policy"`
+	CrisisDetection bool    `json:"crisis_detection"`
+	CrisisRegion    string  `json:"crisis_region"`
+	CrashUploadURL  string  `json:"crash_upload_url"`
+	UpdateURL       string  `json:"update_url"`
+	Telemetry       bool    `json:"telemetry"`
+	TelemetryURL    string  `json:"telemetry_url"`
+	NoveltyGuard    float64 `json:"novelty_guard"`
+	SafetyPolicies  string  `json:"safety_policies"`
+}
+
+func Default() Config {
+	return Config{
+		BaseURL:         "http://localhost:8080",
+		SanitizePolicy:  "flag",
+		CrisisDetection: true,
+		NoveltyGuard:    0.8,
+	}
+}
+
+// option ties a config field to its file key, flag name and env var so
+// every setting can be supplied through any of the three sources.
+type option struct {
+	name  string
+	usage string
+	set   func(c *Config, v string) error
+	get   func(c *Config) string
+}
+
+var options = []option{
+	{"base_url", "EpistemicMe server base URL",
+		func(c *Config, v string) error { c.BaseURL = v; return nil },
+		func(c *Config) string { return c.BaseURL }},
+	{"api_key", "EpistemicMe API key",
+		func(c *Config, v string) error { c.APIKey = v; return nil },
+		func(c *Config) string { return mask(c.APIKey) }},
+	{"sanitize_policy", "handling of suspected prompt injections (flag, strip, block)",
+		func(c *Config, v string) error { c.SanitizePolicy = v; return nil },
+		func(c *Config) string { return c.SanitizePolicy }},
+	{"crisis_detection", "detect crisis language in answers",
+		func(c *Config, v string) error {
+			b, err := strconv.ParseBool(v)
+			c.CrisisDetection = b
+			return err
+		},
+		func(c *Config) string { return strconv.FormatBool(c.CrisisDetection) }},
+	{"crisis_region", "region code for crisis support resources (defaults to the locale)",
+		func(c *Config, v string) error { c.CrisisRegion = strings.ToUpper(v); return nil },
+		func(c *Config) string { return c.CrisisRegion }},
+	{"crash_upload_url", "upload crash reports to this URL (off when empty)",
+		func(c *Config, v string) error { c.CrashUploadURL = v; return nil },
+		func(c *Config) string { return c.CrashUploadURL }},
+	{"update_url", "release endpoint checked by the update command",
+		func(c *Config, v string) error { c.UpdateURL = v; return nil },
+		func(c *Config) string { return c.UpdateURL }},
+	{"telemetry", "collect anonymous usage counts (see the telemetry command)",
+		func(c *Config, v string) error {
+			b, err := strconv.ParseBool(v)
+			c.Telemetry = b
+			return err
+		},
+		func(c *Config) string { return strconv.FormatBool(c.Telemetry) }},
+	{"telemetry_url", "where collected usage counts are sent",
+		func(c *Config, v string) error { c.TelemetryURL = v; return nil },
+		func(c *Config) string { return c.TelemetryURL }},
+	{"novelty_guard", "similarity (0-1) at which a repeated question is replaced; 0 turns the guard off",
+		func(c *Config, v string) error {
+			f, err := strconv.ParseFloat(v, 64)
+			if err == nil && (f < 0 || f > 1) {
+				err = fmt.Errorf("%v is not between 0 and 1", f)
+			}
+			c.NoveltyGuard = f
+			return err
+		},
+		func(c *Config) string { return strconv.FormatFloat(c.NoveltyGuard, 'g', -1, 64) }},
+	{"safety_policies", "content safety policy modes, e.g. self-harm=block,medical-advice=annotate",
+		func(c *Config, v string) error { c.SafetyPolicies = v; return nil },
+		func(c *Config) string { return c.SafetyPolicies }},
+}
+
+func flagName(o option) string { return strings.ReplaceAll(o.name, "_", "-") }
+
+func envName(o option) string { return EnvPrefix + strings.ToUpper(o.name) }
+
+func DefaultPath() string {
+	dir, err := Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "config.json")
+}
+
+// Flags registers one flag per option, plus --config for the file path.
+// Parse the flag set, then pass it to Load.
+func Flags(flags *pflag.FlagSet) {
+	flags.String("config", "", "path to config file (env "+EnvPrefix+"CONFIG)")
+	for _, o := range options {
+		flags.String(flagName(o), "", fmt.Sprintf("%s (env %s)", o.usage, envName(o)))
+		if isBool(o) {
+			// Lets boolean options be given bare, as in --telemetry.
+			flags.Lookup(flagName(o)).NoOptDefVal = "true"
+		}
+	}
+}
+
+// isBool reports whether the Config field behind o is a bool.
+func isBool(o option) bool {
+	i := field(o)
+	return i >= 0 && reflect.TypeOf(Config{}).Field(i).Type.Kind() == reflect.Bool
+}
+
+// field returns the index of the Config field behind o, or -1.
+func field(o option) int {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("json") == o.name {
+			return i
+		}
+	}
+	return -1
+}
+
+func lookup(name string) (option, bool) {
+	for _, o := range options {
+		if o.name == name {
+			return o, true
+		}
+	}
+	return option{}, false
+}
+
+// Path returns the config file in use: --config, then EPISTEMICME_CONFIG,
+// then the default location. flags may be nil.
+func Path(flags *pflag.FlagSet) string {
+	path := DefaultPath()
+	if v := os.Getenv(EnvPrefix + "CONFIG"); v != "" {
+		path = v
+	}
+	if flags != nil {
+		if f := flags.Lookup("config"); f != nil && f.Value.String() != "" {
+			path = f.Value.String()
+		}
+	}
+	return path
+}
+
+// Load resolves the configuration with precedence flags > env > file >
+// defaults. flags may be nil when there are no flags to apply.
+func Load(flags *pflag.FlagSet) (Config, error) {
+	cfg := Default()
+	if err := cfg.applyFile(Path(flags)); err != nil {
+		return cfg, err
+	}
+
+	for _, o := range options {
+		if v, ok := os.LookupEnv(envName(o)); ok {
+			if err := o.set(&cfg, v); err != nil {
+				return cfg, &ValueError{Source: envName(o), Err: err}
+			}
+		}
+	}
+
+	if flags != nil {
+		var err error
+		flags.Visit(func(f *pflag.Flag) {
+			for _, o := range options {
+				if f.Name == flagName(o) && err == nil {
+					if setErr := o.set(&cfg, f.Value.String()); setErr != nil {
+						err = &ValueError{Source: "--" + f.Name, Err: setErr}
+					}
+				}
+			}
+		})
+		if err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// ValueError reports an invalid value given through a flag or env var.
+type ValueError struct {
+	Source string
+	Err    error
+}
+
+func (e *ValueError) Error() string { return e.Source + ": " + e.Err.Error() }
+
+func (e *ValueError) Unwrap() error { return e.Err }
+
+func (c *Config) applyFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// Set changes the named option, checking the value as Load would.
+func (c *Config) Set(name, value string) error {
+	o, ok := lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown option %q", name)
+	}
+	return o.set(c, value)
+}
+
+// Save writes one option to the config file at path, keeping everything
+// else in the file. Flags and env vars still take precedence over the saved
+// value when the config is loaded.
+func Save(path, name, value string) error {
+	if path == "" {
+		return errors.New("no location for the config file")
+	}
+	o, ok := lookup(name)
+	if !ok {
+		return fmt.Errorf("unknown option %q", name)
+	}
+	var c Config
+	if err := o.set(&c, value); err != nil {
+		return &ValueError{Source: name, Err: err}
+	}
+
+	values := map[string]any{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if values == nil {
+			values = map[string]any{}
+		}
+	}
+	values[name] = reflect.ValueOf(c).Field(field(o)).Interface()
+
+	if data, err = json.MarshalIndent(values, "", "  "); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// Entries lists every option with its effective value, secrets masked.
+// URLs can carry credentials too, so their userinfo and query values are
+// masked as well.
+func (c *Config) Entries() [][2]string {
+	entries := make([][2]string, 0, len(options))
+	for _, o := range options {
+		v := o.get(c)
+		if isURL(o) {
+			v = maskURL(v)
+		}
+		entries = append(entries, [2]string{o.name, v})
+	}
+	return entries
+}
+
+// Portable prepares the contents of a config file for copying to another
+// machine. The API key is left out, and URL options are masked as in
+// Entries, since they can carry credentials too.
+func Portable(data []byte) (json.RawMessage, error) {
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	for _, o := range options {
+		v, ok := values[o.name].(string)
+		switch {
+		case o.name == "api_key":
+			delete(values, o.name)
+		case ok && isURL(o):
+			values[o.name] = maskURL(v)
+		}
+	}
+	return json.Marshal(values)
+}
+
+// RedactArgs masks the values of secret and URL options in command-line
+// args, for crash reports and the like.
+func RedactArgs(args []string) []string {
+	out := make([]string, len(args))
+	var pending *option
+	for i, a := range args {
+		if pending != nil {
+			out[i] = redact(*pending, a)
+			pending = nil
+			continue
+		}
+		out[i] = a
+		flag, ok := strings.CutPrefix(a, "--")
+		if !ok {
+			continue
+		}
+		name, value, hasValue := strings.Cut(flag, "=")
+		for _, o := range options {
+			if flagName(o) != name || o.name != "api_key" && !isURL(o) {
+				continue
+			}
+			if hasValue {
+				out[i] = "--" + name + "=" + redact(o, value)
+			} else {
+				pending = &o
+			}
+		}
+	}
+	return out
+}
+
+func isURL(o option) bool { return strings.HasSuffix(o.name, "_url") }
+
+func redact(o option, v string) string {
+	if isURL(o) {
+		return maskURL(v)
+	}
+	return "****"
+}
+
+// maskURL hides the userinfo and query values of a URL, keeping the host
+// and path that are useful for debugging.
+func maskURL(v string) string {
+	if v == "" {
+		return ""
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return "****"
+	}
+	hadUser := u.User != nil
+	u.User = nil
+	if u.RawQuery != "" {
+		keys := make([]string, 0)
+		for k := range u.Query() {
+			keys = append(keys, k+"=****")
+		}
+		sort.Strings(keys)
+		u.RawQuery = strings.Join(keys, "&")
+	}
+	u.Fragment = ""
+	if hadUser {
+		// url.User would escape the asterisks.
+		return strings.Replace(u.String(), "://", "://****@", 1)
+	}
+	return u.String()
+}
+
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
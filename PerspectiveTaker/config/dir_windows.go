@@ -0,0 +1,18 @@
+//go:build windows
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Dir is where the CLI keeps its config file and local state, under
+// %APPDATA% as Windows applications do.
+func Dir() (string, error) {
+	appData, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appData, "perspective-taker"), nil
+}
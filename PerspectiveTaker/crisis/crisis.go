@@ -0,0 +1,85 @@
+package crisis
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Detector decides whether a user's answer contains crisis indicators.
+// Detection runs locally; answers are never sent anywhere for this check.
+type Detector interface {
+	Detect(text string) bool
+}
+
+type KeywordDetector struct {
+	patterns []*regexp.Regexp
+}
+
+func NewKeywordDetector() *KeywordDetector {
+	return &KeywordDetector{patterns: []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(kill|hurt|harm|cut)(ting|ing)? myself\b`),
+		regexp.MustCompile(`(?i)\b(suicide|suicidal|self[- ]harm(ing)?)\b`),
+		regexp.MustCompile(`(?i)\b(want|wanted|going|plan(ning)?) to (die|end it( all)?|end my life)\b`),
+		regexp.MustCompile(`(?i)\bend(ing)? my (own )?life\b`),
+		regexp.MustCompile(`(?i)\b(better off dead|no (reason|point) (to|in) (live|living|going on))\b`),
+		regexp.MustCompile(`(?i)\b(can'?t|cannot) go on( anymore| any more| like this)?\b`),
+	}}
+}
+
+func (d *KeywordDetector) Detect(text string) bool {
+	for _, re := range d.patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+type Resource struct {
+	Name    string
+	Contact string
+}
+
+var international = []Resource{
+	{"Find a Helpline (international directory)", "https://findahelpline.com"},
+}
+
+var resourcesByRegion = map[string][]Resource{
+	"US": {{"988 Suicide & Crisis Lifeline", "call or text 988"}, {"Crisis Text Line", "text HOME to 741741"}},
+	"CA": {{"9-8-8 Suicide Crisis Helpline", "call or text 988"}},
+	"GB": {{"Samaritans", "call 116 123"}, {"Shout", "text SHOUT to 85258"}},
+	"IE": {{"Samaritans", "call 116 123"}},
+	"AU": {{"Lifeline", "call 13 11 14"}},
+	"NZ": {{"Need to talk?", "call or text 1737"}},
+	"DE": {{"TelefonSeelsorge", "call 0800 111 0 111"}},
+	"FR": {{"3114 Numéro national de prévention du suicide", "call 3114"}},
+}
+
+// Resources returns the support resources for a two-letter region code,
+// always ending with the international directory.
+func Resources(region string) []Resource {
+	local := resourcesByRegion[strings.ToUpper(region)]
+	return append(append([]Resource{}, local...), international...)
+}
+
+// RegionFromEnv guesses the user's region from the locale environment,
+// e.g. LANG=en_GB.UTF-8 gives "GB". It returns "" when unknown.
+func RegionFromEnv() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if region := regionFromLocale(os.Getenv(key)); region != "" {
+			return region
+		}
+	}
+	return ""
+}
+
+func regionFromLocale(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	_, region, ok := strings.Cut(strings.ReplaceAll(locale, "-", "_"), "_")
+	if !ok || len(region) != 2 {
+		return ""
+	}
+	return strings.ToUpper(region)
+}
@@ -0,0 +1,57 @@
+package crisis
+
+import "testing"
+
+func TestKeywordDetector(t *testing.T) {
+	d := NewKeywordDetector()
+	for _, text := range []string{
+		"Sometimes I want to hurt myself.",
+		"I've been cutting myself again",
+		"I have thought about suicide.",
+		"I'm planning to end it all",
+		"Honestly I'd be better off dead.",
+		"I can't go on like this",
+	} {
+		if !d.Detect(text) {
+			t.Errorf("Detect(%q) = false, want true", text)
+		}
+	}
+	for _, text := range []string{
+		"That comment really hurt me.",
+		"My boss cut me off in the meeting.",
+		"The heat nearly killed me on that hike.",
+		"I think I am the same person because of my memories.",
+		"I went on to study philosophy.",
+	} {
+		if d.Detect(text) {
+			t.Errorf("Detect(%q) = true, want false", text)
+		}
+	}
+}
+
+func TestResources(t *testing.T) {
+	got := Resources("gb")
+	if len(got) != 3 || got[0].Name != "Samaritans" || got[len(got)-1] != international[0] {
+		t.Errorf("Resources(gb) = %v", got)
+	}
+	if got := Resources("ZZ"); len(got) != 1 || got[0] != international[0] {
+		t.Errorf("Resources(ZZ) = %v, want only the international directory", got)
+	}
+}
+
+func TestRegionFromLocale(t *testing.T) {
+	tests := map[string]string{
+		"en_GB.UTF-8":      "GB",
+		"de-DE":            "DE",
+		"fr_FR@euro":       "FR",
+		"C":                "",
+		"en":               "",
+		"zh_Hans_CN.UTF-8": "",
+		"":                 "",
+	}
+	for locale, want := range tests {
+		if got := regionFromLocale(locale); got != want {
+			t.Errorf("regionFromLocale(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"perspective_taker/conductor"
+	"perspective_taker/store"
+	"perspective_taker/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+func dialogueCmd() *cobra.Command {
+	var (
+		conductorName string
+		maxTurns      int
+		tui           bool
+	)
+	cmd := &cobra.Command{
+		Use:   "dialogue",
+		Short: "Start and manage a dialogue",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			record := store.NewSession(conductorName, maxTurns)
+			session, err := newSession(record)
+			if err != nil {
+				return err
+			}
+			if tui {
+				return runDialogueTUI(cmd.Context(), session, &record)
+			}
+			return runDialogue(cmd.Context(), session, &record)
+		},
+	}
+	cmd.Flags().BoolVar(&tui, "tui", false, "run the dialogue in a full-screen terminal UI")
+	cmd.Flags().StringVar(&conductorName, "conductor", "server", "questioning policy: server (every question from the server source, currently a fixed script) or hybrid (mixes in local follow-ups and recaps)")
+	cmd.Flags().IntVar(&maxTurns, "max-turns", 0, "end the dialogue after this many answers (0 for no limit)")
+	return cmd
+}
+
+// newSession builds a conductor session for a stored session record,
+// replaying any turns it already has.
+func newSession(record store.Session) (*conductor.Session, error) {
+	var c conductor.Conductor
+	switch record.Conductor {
+	case "server":
+		c = conductor.ServerOnly{MaxTurns: record.MaxTurns}
+	case "hybrid":
+		c = conductor.Hybrid{LocalEvery: 3, SummarizeEvery: 5, MaxTurns: record.MaxTurns}
+	default:
+		return nil, usageError{fmt.Errorf("unknown conductor %q (want server or hybrid)", record.Conductor)}
+	}
+
+	// Stand-in for the EpistemicMe backend until the CLI can use the Go SDK.
+	script := &conductor.Script{Questions: []string{
+		"What are your initial thoughts on the concept of personal identity?",
+		"How does this relate to the continuity or change over time?",
+	}}
+	session := &conductor.Session{
+		Conductor: c,
+		Server: screened{conductor.Novel{
+			Source:    script,
+			Fallback:  conductor.FollowUp{},
+			Threshold: cfg.NoveltyGuard,
+			Retries:   2,
+		}},
+		Local:      screened{conductor.FollowUp{}},
+		Summarizer: screenedSummarizer{conductor.Recap{}},
+		Responder:  screenedResponder{conductor.Reflect{}},
+	}
+
+	for _, t := range record.Turns {
+		action, err := conductor.ParseAction(t.Source)
+		if err != nil {
+			return nil, fmt.Errorf("session %s: %w", record.ID, err)
+		}
+		if action == conductor.AskServer {
+			script.Served++
+		}
+		session.State.Turns = append(session.State.Turns, conductor.Turn{
+			Action:      action,
+			Question:    t.Question,
+			Answer:      t.Answer,
+			Time:        t.Time,
+			Perspective: t.Perspective,
+		})
+	}
+	session.Perspective = record.Perspective
+	return session, nil
+}
+
+// syncTurns copies the conductor state into the record.
+func syncTurns(record *store.Session, session *conductor.Session) {
+	record.Turns = make([]store.Turn, 0, len(session.State.Turns))
+	for _, t := range session.State.Turns {
+		record.Turns = append(record.Turns, store.Turn{
+			Source:      t.Action.String(),
+			Question:    t.Question,
+			Answer:      t.Answer,
+			Time:        t.Time.UTC(),
+			Perspective: t.Perspective,
+		})
+	}
+	record.Perspective = session.Perspective
+}
+
+// saveSession copies the conductor state into the record and persists it.
+// Failing to save is logged rather than interrupting the dialogue.
+func saveSession(record *store.Session, session *conductor.Session) {
+	syncTurns(record, session)
+	sessions, err := openStore()
+	if err == nil {
+		err = sessions.Save(*record)
+	}
+	if err != nil {
+		logger.Error("saving session failed", "session", record.ID, "err", err)
+	}
+}
+
+// screened passes every question from a source through the content safety filter.
+type screened struct {
+	source conductor.QuestionSource
+}
+
+func (s screened) Question(ctx context.Context, st conductor.State) (string, error) {
+	q, err := s.source.Question(ctx, st)
+	if err != nil {
+		return "", err
+	}
+	return screenQuestion(q), nil
+}
+
+// screenedSummarizer passes summaries through the content safety filter.
+type screenedSummarizer struct {
+	summarizer conductor.Summarizer
+}
+
+func (s screenedSummarizer) Summarize(ctx context.Context, st conductor.State) (string, error) {
+	summary, err := s.summarizer.Summarize(ctx, st)
+	if err != nil {
+		return "", err
+	}
+	return screen("summary", summary), nil
+}
+
+// screenedResponder passes the agent's replies to /ask through the content
+// safety filter.
+type screenedResponder struct {
+	responder conductor.Responder
+}
+
+func (s screenedResponder) Respond(ctx context.Context, st conductor.State, question string) (string, error) {
+	reply, err := s.responder.Respond(ctx, st, question)
+	if err != nil {
+		return "", err
+	}
+	return screen("agent", reply), nil
+}
+
+type turn struct {
+	Source      string    `json:"source"`
+	Question    string    `json:"question"`
+	Answer      string    `json:"answer,omitempty"`
+	Time        time.Time `json:"time"`
+	Perspective string    `json:"perspective,omitempty"`
+}
+
+type transcript struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	Turns     []turn    `json:"turns"`
+	// Reflection is the user's own take on how the session went. It is kept
+	// apart from the answers because it is about their thinking process, not
+	// the topic.
+	Reflection string `json:"reflection,omitempty"`
+}
+
+func runDialogue(ctx context.Context, session *conductor.Session, record *store.Session) error {
+	if len(record.Turns) == 0 {
+		fmt.Fprintln(ui(), "Starting dialogue...")
+		fmt.Fprintf(ui(), "Dialectic session %s started. Here's your first question:\n", record.ID)
+	} else {
+		fmt.Fprintf(ui(), "Resuming dialectic session %s where you left off:\n", record.ID)
+	}
+
+	for {
+		t, pending := session.Pending()
+		if !pending {
+			var err error
+			if t, err = session.Next(ctx); err != nil {
+				return err
+			}
+			if t.Action == conductor.End {
+				fmt.Fprintln(ui(), "That's the end of this dialogue.")
+				break
+			}
+			saveSession(record, session)
+		}
+		fmt.Fprintln(ui(), t.Question)
+		if t.Action == conductor.Summarize {
+			continue
+		}
+
+		response, err := readAnswer(ctx, session, record)
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(ui())
+			fmt.Fprintf(ui(), "Session %s saved. Continue it later with: perspective-taker resume %s\n", record.ID, record.ID)
+			if jsonOutput {
+				return printJSON(transcriptOf(*record))
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if response == "end" {
+			fmt.Fprintln(ui(), "Ending dialogue...")
+			break
+		}
+
+		logger.Debug("answer submitted", "session", record.ID, "turn", session.State.Asked(), "source", t.Action.String(), "length", len(response))
+		if err := session.Answer(response); err != nil {
+			return err
+		}
+		saveSession(record, session)
+		fmt.Fprintf(ui(), "You answered: %s\n", response)
+	}
+
+	record.Status = store.StatusEnded
+	// Piped input has nobody to reflect, so only ask at a terminal, and treat
+	// running out of input as skipping the question.
+	if session.State.Answered() > 0 && terminal.IsTerminal(os.Stdin) {
+		reflection, err := readResponse("Before you go: what surprised you about your own thinking in this dialogue? (press enter to skip): ")
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(ui())
+			err = nil
+		}
+		if err != nil {
+			saveSession(record, session)
+			return err
+		}
+		record.Reflection = reflection
+	}
+	saveSession(record, session)
+
+	if jsonOutput {
+		return printJSON(transcriptOf(*record))
+	}
+	return nil
+}
+
+const askPrefix = "/ask "
+
+// readAnswer reads the user's answer to the pending question. Lines starting
+// with /ask are questions for the agent; they are answered and the user is
+// prompted again.
+func readAnswer(ctx context.Context, session *conductor.Session, record *store.Session) (string, error) {
+	for {
+		response, err := readResponse("Enter your response (type 'end' to finish dialogue, or '/ask <question>' to ask the agent): ")
+		if err != nil {
+			return "", err
+		}
+		question, ok := strings.CutPrefix(response, askPrefix)
+		if !ok {
+			return response, nil
+		}
+
+		t, err := session.Ask(ctx, strings.TrimSpace(question))
+		if err != nil {
+			return "", err
+		}
+		logger.Debug("user asked the agent", "session", record.ID, "length", len(t.Question))
+		saveSession(record, session)
+		fmt.Fprintln(ui(), t.Answer)
+	}
+}
+
+func transcriptOf(record store.Session) transcript {
+	result := transcript{
+		ID:         record.ID,
+		Status:     record.Status,
+		CreatedAt:  record.CreatedAt,
+		Turns:      []turn{},
+		Reflection: record.Reflection,
+	}
+	for _, t := range record.Turns {
+		if t.Question == "" || t.Answer == "" && t.Source != conductor.Summarize.String() {
+			continue
+		}
+		result.Turns = append(result.Turns, turn{Source: t.Source, Question: t.Question, Answer: t.Answer, Time: t.Time, Perspective: t.Perspective})
+	}
+	return result
+}
+
+// readResponse prompts until the user gives a response that the input
+// sanitizer lets through, and screens that response for crisis language.
+func readResponse(prompt string) (string, error) {
+	for {
+		fmt.Fprint(ui(), prompt)
+		response, err := stdin.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("reading response: %w", err)
+		}
+		if text, ok := checkResponse(ui(), response); ok {
+			return text, nil
+		}
+	}
+}
+
+// checkResponse runs a response through the input sanitizer and crisis
+// detection, writing any notices for the user to out. It reports false if
+// the response was blocked and has to be entered again.
+func checkResponse(out io.Writer, response string) (string, bool) {
+	checked := inputSanitizer.Check(strings.TrimSpace(response))
+	if checked.Flagged() {
+		logger.Info("response matched injection patterns", "patterns", checked.Matches, "policy", inputSanitizer.Policy.String())
+	}
+	if checked.Blocked {
+		fmt.Fprintln(out, "Response blocked: it looks like an attempt to instruct the model. Please rephrase.")
+		return "", false
+	}
+	if checked.Flagged() {
+		fmt.Fprintln(out, "Warning: response matched injection patterns:", strings.Join(checked.Matches, ", "))
+	}
+	if crisisDetector != nil && crisisDetector.Detect(checked.Text) {
+		logger.Info("crisis language detected, showing support resources", "region", crisisRegion)
+		writeCrisisResources(out)
+	}
+	return checked.Text, true
+}
+
+func screenQuestion(question string) string {
+	return screen("question", question)
+}
+
+// screen runs generated text from source through the content safety filter.
+func screen(source, text string) string {
+	verdict := outputFilter.Screen(source, text)
+	if len(verdict.Triggers) > 0 {
+		logger.Info("safety policy triggered", "policies", verdict.Triggers, "blocked", verdict.Blocked)
+	}
+	return verdict.Text
+}
+
+func askQuestion(question string) string {
+	q := screenQuestion(question)
+	fmt.Fprintln(ui(), q)
+	return q
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"perspective_taker/conductor"
+	"perspective_taker/store"
+
+	"github.com/spf13/cobra"
+)
+
+// questionMatch is how similar two questions must be to be compared.
+const questionMatch = 0.5
+
+func diffSessionsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff-sessions <sessionA> <sessionB>",
+		Short: "Compare how two sessions answered the same questions",
+		Long: `Compare how two sessions answered the same questions. Questions are paired
+when they share most of their content words, so the sessions don't have to
+ask them in the same order or with the same wording.`,
+		Args: usageArgs(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sessions, err := openStore()
+			if err != nil {
+				return err
+			}
+			var records [2]store.Session
+			for i, id := range args {
+				records[i], err = sessions.Get(id)
+				if errors.Is(err, store.ErrNotFound) {
+					return usageError{err}
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return showSessionDiff(diffSessions(records[0], records[1]))
+		},
+	}
+}
+
+type answerDiff struct {
+	Question  string  `json:"question"`
+	QuestionB string  `json:"question_b,omitempty"`
+	AnswerA   string  `json:"answer_a,omitempty"`
+	AnswerB   string  `json:"answer_b,omitempty"`
+	Overlap   float64 `json:"overlap"`
+}
+
+type sessionDiff struct {
+	A       string       `json:"a"`
+	B       string       `json:"b"`
+	Matched []answerDiff `json:"matched"`
+	OnlyA   []answerDiff `json:"only_a"`
+	OnlyB   []answerDiff `json:"only_b"`
+}
+
+func answered(s store.Session) []store.Turn {
+	var turns []store.Turn
+	for _, t := range s.Turns {
+		if t.Answer != "" && (t.Source == conductor.AskServer.String() || t.Source == conductor.AskLocal.String()) {
+			turns = append(turns, t)
+		}
+	}
+	return turns
+}
+
+// diffSessions pairs each answered question in a with the most similar
+// unpaired question in b.
+func diffSessions(a, b store.Session) sessionDiff {
+	d := sessionDiff{A: a.ID, B: b.ID, Matched: []answerDiff{}, OnlyA: []answerDiff{}, OnlyB: []answerDiff{}}
+	turnsB := answered(b)
+	used := make([]bool, len(turnsB))
+	for _, ta := range answered(a) {
+		best, bestScore := -1, questionMatch
+		for j, tb := range turnsB {
+			if score := overlap(ta.Question, tb.Question); !used[j] && score >= bestScore {
+				if best < 0 || score > bestScore {
+					best, bestScore = j, score
+				}
+			}
+		}
+		if best < 0 {
+			d.OnlyA = append(d.OnlyA, answerDiff{Question: ta.Question, AnswerA: ta.Answer})
+			continue
+		}
+		used[best] = true
+		tb := turnsB[best]
+		diff := answerDiff{
+			Question: ta.Question,
+			AnswerA:  ta.Answer,
+			AnswerB:  tb.Answer,
+			Overlap:  overlap(ta.Answer, tb.Answer),
+		}
+		if tb.Question != ta.Question {
+			diff.QuestionB = tb.Question
+		}
+		d.Matched = append(d.Matched, diff)
+	}
+	for j, tb := range turnsB {
+		if !used[j] {
+			d.OnlyB = append(d.OnlyB, answerDiff{Question: tb.Question, AnswerB: tb.Answer})
+		}
+	}
+	return d
+}
+
+// overlap is conductor.Similarity, except that texts without content words,
+// such as "ok" and "no", only count as the same if they are equal.
+func overlap(a, b string) float64 {
+	if len(conductor.ContentWords(a)) == 0 || len(conductor.ContentWords(b)) == 0 {
+		if normalize(a) == normalize(b) {
+			return 1
+		}
+		return 0
+	}
+	return conductor.Similarity(a, b)
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.Trim(s, " \t.!?"))), " ")
+}
+
+func showSessionDiff(d sessionDiff) error {
+	if jsonOutput {
+		return printJSON(d)
+	}
+	if len(d.Matched) == 0 {
+		fmt.Printf("Sessions %s and %s have no questions in common.\n", d.A, d.B)
+	}
+	for _, m := range d.Matched {
+		fmt.Println(m.Question)
+		if m.QuestionB != "" {
+			fmt.Printf("  (%s asked: %s)\n", d.B, m.QuestionB)
+		}
+		fmt.Printf("  %s: %s\n", d.A, m.AnswerA)
+		fmt.Printf("  %s: %s\n", d.B, m.AnswerB)
+		fmt.Printf("  %s\n\n", describeOverlap(m.Overlap))
+	}
+	if len(d.OnlyA) > 0 {
+		fmt.Printf("Only in %s:\n", d.A)
+		for _, x := range d.OnlyA {
+			fmt.Printf("  %s\n    %s\n", x.Question, x.AnswerA)
+		}
+	}
+	if len(d.OnlyB) > 0 {
+		fmt.Printf("Only in %s:\n", d.B)
+		for _, x := range d.OnlyB {
+			fmt.Printf("  %s\n    %s\n", x.Question, x.AnswerB)
+		}
+	}
+	return nil
+}
+
+func describeOverlap(overlap float64) string {
+	switch {
+	case overlap >= 0.8:
+		return "Answers are essentially the same."
+	case overlap >= 0.4:
+		return "Answers overlap partly."
+	case overlap > 0:
+		return "Answers are mostly different."
+	}
+	return "Answers have nothing in common."
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"perspective_taker/store"
+)
+
+func TestOverlapShortAnswers(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"ok", "no", 0},
+		{"ok", "OK.", 1},
+		{"yes", "Memory makes me who I am", 0},
+		{"Memory makes me who I am", "Memory makes me who I am", 1},
+	}
+	for _, tt := range tests {
+		if got := overlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("overlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDiffSessions(t *testing.T) {
+	turn := func(q, a string) store.Turn { return store.Turn{Source: "server", Question: q, Answer: a} }
+	a := store.Session{ID: "a", Turns: []store.Turn{
+		turn("What makes you who you are?", "ok"),
+		turn("Does identity change over time?", "It changes slowly"),
+	}}
+	b := store.Session{ID: "b", Turns: []store.Turn{
+		turn("Does your identity change over time?", "It changes quickly"),
+		turn("What makes you who you are?", "no"),
+		turn("Is memory essential?", "Yes"),
+	}}
+
+	d := diffSessions(a, b)
+	if len(d.Matched) != 2 || len(d.OnlyA) != 0 || len(d.OnlyB) != 1 {
+		t.Fatalf("got %d matched, %d only in a, %d only in b; want 2, 0, 1", len(d.Matched), len(d.OnlyA), len(d.OnlyB))
+	}
+	if m := d.Matched[0]; m.AnswerB != "no" || m.Overlap != 0 {
+		t.Errorf("first pair = %+v, want answer \"no\" with no overlap", m)
+	}
+	if m := d.Matched[1]; m.QuestionB != "Does your identity change over time?" || m.AnswerB != "It changes quickly" {
+		t.Errorf("second pair = %+v, want the reworded question", m)
+	}
+	if d.OnlyB[0].Question != "Is memory essential?" {
+		t.Errorf("only in b = %+v", d.OnlyB)
+	}
+}
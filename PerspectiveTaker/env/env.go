@@ -0,0 +1,142 @@
+// Package env wraps a dialogue with a simulated user in a Gym-style
+// environment, so question-selection policies can be trained against it.
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"perspective_taker/conductor"
+	"perspective_taker/simulator"
+)
+
+// Actions are the choices a policy can make at each step.
+var Actions = []conductor.Action{conductor.AskServer, conductor.AskLocal, conductor.Summarize, conductor.End}
+
+type Observation struct {
+	// Answered counts the questions answered so far in the episode.
+	Answered int
+	Question string
+	Answer   string
+	// NewWords counts content words in Answer that the user had not used
+	// before in the episode.
+	NewWords int
+	// Vocabulary counts the distinct content words used in the episode.
+	Vocabulary int
+}
+
+// DialecticEnv runs one dialogue per episode. The policy takes the place of
+// the conductor: each Step carries out the chosen action and, for questions,
+// has the simulated user answer. The reward is the number of new content
+// words the answer brings up, minus TurnCost for every step, so policies are
+// rewarded for questions that draw out something new.
+type DialecticEnv struct {
+	// NewSession returns a fresh session for an episode. Its Conductor is
+	// replaced by the policy's actions.
+	NewSession func() *conductor.Session
+	// NewUser returns the simulated user for the given episode number.
+	NewUser  func(episode int) simulator.Answerer
+	MaxSteps int
+	TurnCost float64
+
+	session *conductor.Session
+	user    simulator.Answerer
+	seen    map[string]bool
+	steps   int
+	episode int
+	done    bool
+}
+
+// Reset starts a new episode and returns its initial observation.
+func (e *DialecticEnv) Reset(ctx context.Context) (Observation, error) {
+	if e.NewSession == nil || e.NewUser == nil {
+		return Observation{}, errors.New("env: NewSession and NewUser must be set")
+	}
+	e.episode++
+	e.session = e.NewSession()
+	e.user = e.NewUser(e.episode)
+	e.seen = map[string]bool{}
+	e.steps = 0
+	e.done = false
+	return Observation{}, nil
+}
+
+// Step carries out action and returns the observation, the reward and
+// whether the episode is over.
+func (e *DialecticEnv) Step(ctx context.Context, action conductor.Action) (Observation, float64, bool, error) {
+	if e.session == nil || e.done {
+		return Observation{}, 0, true, errors.New("env: call Reset before Step")
+	}
+	if !valid(action) {
+		return Observation{}, 0, false, fmt.Errorf("env: %v is not a valid action", action)
+	}
+
+	e.steps++
+	obs := Observation{Answered: e.session.State.Answered(), Vocabulary: len(e.seen)}
+	reward := -e.TurnCost
+	if action == conductor.Summarize && e.session.Summarizer == nil {
+		// Without a summarizer the session would keep skipping the summary
+		// and asking the conductor again, so it is a wasted step instead.
+		e.done = e.MaxSteps > 0 && e.steps >= e.MaxSteps
+		return obs, reward, e.done, nil
+	}
+
+	e.session.Conductor = chosen(action)
+	t, err := e.session.Next(ctx)
+	if err != nil {
+		return Observation{}, 0, false, err
+	}
+	obs.Question = t.Question
+
+	switch t.Action {
+	case conductor.End:
+		e.done = true
+		return obs, reward, true, nil
+	case conductor.AskServer, conductor.AskLocal:
+		answer, err := e.user.Answer(ctx, e.session.State, t.Question)
+		if err != nil {
+			return Observation{}, 0, false, err
+		}
+		if err := e.session.Answer(answer); err != nil {
+			return Observation{}, 0, false, err
+		}
+		for w := range conductor.ContentWords(answer) {
+			if !e.seen[w] {
+				e.seen[w] = true
+				obs.NewWords++
+			}
+		}
+		obs.Answer = answer
+		obs.Answered++
+		obs.Vocabulary = len(e.seen)
+		reward += float64(obs.NewWords)
+	}
+
+	e.done = e.MaxSteps > 0 && e.steps >= e.MaxSteps
+	return obs, reward, e.done, nil
+}
+
+// State returns the dialogue of the current episode.
+func (e *DialecticEnv) State() conductor.State {
+	if e.session == nil {
+		return conductor.State{}
+	}
+	return e.session.State
+}
+
+func valid(a conductor.Action) bool {
+	for _, v := range Actions {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
+
+// chosen is a conductor that always picks the policy's action.
+type chosen conductor.Action
+
+func (c chosen) Next(ctx context.Context, s conductor.State) (conductor.Action, error) {
+	return conductor.Action(c), nil
+}
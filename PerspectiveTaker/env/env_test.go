@@ -0,0 +1,102 @@
+package env
+
+import (
+	"context"
+	"testing"
+
+	"perspective_taker/conductor"
+	"perspective_taker/simulator"
+)
+
+// scripted answers with the next of its answers.
+type scripted struct {
+	answers []string
+	n       int
+}
+
+func (s *scripted) Answer(ctx context.Context, st conductor.State, question string) (string, error) {
+	a := s.answers[min(s.n, len(s.answers)-1)]
+	s.n++
+	return a, nil
+}
+
+func newEnv(summarizer conductor.Summarizer, maxSteps int) *DialecticEnv {
+	return &DialecticEnv{
+		NewSession: func() *conductor.Session {
+			return &conductor.Session{
+				Server:     &conductor.Script{Questions: []string{"What is identity?", "Does it change?"}},
+				Local:      conductor.FollowUp{},
+				Summarizer: summarizer,
+			}
+		},
+		NewUser: func(episode int) simulator.Answerer {
+			return &scripted{answers: []string{"memory shapes identity", "memory matters"}}
+		},
+		MaxSteps: maxSteps,
+		TurnCost: 0.5,
+	}
+}
+
+func TestStep(t *testing.T) {
+	ctx := context.Background()
+	e := newEnv(conductor.Recap{}, 0)
+	if _, err := e.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		action     conductor.Action
+		newWords   int
+		reward     float64
+		answered   int
+		vocabulary int
+		done       bool
+	}{
+		{conductor.AskServer, 3, 2.5, 1, 3, false},
+		{conductor.Summarize, 0, -0.5, 1, 3, false},
+		{conductor.AskLocal, 1, 0.5, 2, 4, false},
+		{conductor.End, 0, -0.5, 2, 4, true},
+	}
+	for _, s := range steps {
+		obs, reward, done, err := e.Step(ctx, s.action)
+		if err != nil {
+			t.Fatalf("%v: %v", s.action, err)
+		}
+		if obs.NewWords != s.newWords || reward != s.reward || obs.Answered != s.answered || obs.Vocabulary != s.vocabulary || done != s.done {
+			t.Errorf("%v: got %+v, reward %v, done %v; want %d new words, reward %v, %d answered, vocabulary %d, done %v",
+				s.action, obs, reward, done, s.newWords, s.reward, s.answered, s.vocabulary, s.done)
+		}
+	}
+	if _, _, _, err := e.Step(ctx, conductor.AskServer); err == nil {
+		t.Error("Step after the episode ended succeeded, want an error")
+	}
+
+	if _, err := e.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(e.State().Turns); got != 0 {
+		t.Errorf("new episode starts with %d turns", got)
+	}
+}
+
+func TestStepLimits(t *testing.T) {
+	ctx := context.Background()
+	e := newEnv(nil, 2)
+	if _, _, _, err := e.Step(ctx, conductor.AskServer); err == nil {
+		t.Error("Step before Reset succeeded, want an error")
+	}
+	e.Reset(ctx)
+
+	if _, _, _, err := e.Step(ctx, conductor.UserAsk); err == nil {
+		t.Error("UserAsk was accepted as a policy action")
+	}
+
+	// Without a summarizer, summarizing only costs a step.
+	obs, reward, done, err := e.Step(ctx, conductor.Summarize)
+	if err != nil || reward != -0.5 || done || obs.Answered != 0 || len(e.State().Turns) != 0 {
+		t.Errorf("wasted summary: %+v, reward %v, done %v, err %v, %d turns", obs, reward, done, err, len(e.State().Turns))
+	}
+	if _, _, done, _ := e.Step(ctx, conductor.AskServer); !done {
+		t.Error("episode not done after MaxSteps")
+	}
+}
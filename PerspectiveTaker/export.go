@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"perspective_taker/conductor"
+	"perspective_taker/store"
+
+	"github.com/spf13/cobra"
+)
+
+func exportCmd() *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "export <sessionID>",
+		Short: "Print the transcript of a saved session as Markdown or JSON",
+		Args:  usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "md" && format != "json" {
+				return usageError{fmt.Errorf("unknown format %q (want md or json)", format)}
+			}
+			sessions, err := openStore()
+			if err != nil {
+				return err
+			}
+			record, err := sessions.Get(args[0])
+			if errors.Is(err, store.ErrNotFound) {
+				return usageError{err}
+			}
+			if err != nil {
+				return err
+			}
+
+			t := transcriptOf(record)
+			if format == "json" || jsonOutput {
+				return printJSON(t)
+			}
+			fmt.Print(markdownOf(t))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "md", "transcript format: md or json")
+	return cmd
+}
+
+const exportTimeFormat = "2006-01-02 15:04 MST"
+
+func markdownOf(t transcript) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Dialectic session %s\n\n", t.ID)
+	fmt.Fprintf(&b, "Started %s, %s.\n", t.CreatedAt.Local().Format(exportTimeFormat), t.Status)
+
+	n := 0
+	for _, turn := range t.Turns {
+		switch turn.Source {
+		case conductor.Summarize.String():
+			fmt.Fprintf(&b, "\n## Summary\n\n%s\n", turn.Question)
+		case conductor.UserAsk.String():
+			fmt.Fprintf(&b, "\n## You asked\n\n*%s*\n\n> %s\n\n%s\n", stamp(turn), quote(turn.Question), turn.Answer)
+		default:
+			n++
+			fmt.Fprintf(&b, "\n## Question %d\n\n*%s*\n\n> %s\n\n%s\n", n, stamp(turn), quote(turn.Question), turn.Answer)
+		}
+	}
+	if t.Reflection != "" {
+		fmt.Fprintf(&b, "\n## Reflection\n\n%s\n", t.Reflection)
+	}
+	return b.String()
+}
+
+// stamp is the time of a turn and the perspective the user took in it.
+func stamp(t turn) string {
+	s := t.Time.Local().Format(exportTimeFormat)
+	if t.Perspective != "" {
+		s += ", as " + t.Perspective
+	}
+	return s
+}
+
+// quote keeps multi-line text inside a Markdown blockquote.
+func quote(text string) string {
+	return strings.ReplaceAll(text, "\n", "\n> ")
+}
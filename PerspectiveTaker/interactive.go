@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func runInteractive() error {
+	fmt.Fprintln(ui(), "Perspective Taker CLI")
+	fmt.Fprintln(ui(), "Type 'help' for available commands or 'exit' to quit")
+
+	sessionJSON := jsonOutput
+	for {
+		// --json on one line applies to that line only.
+		jsonOutput = sessionJSON
+		fmt.Fprint(ui(), "Enter command: ")
+		input, err := stdin.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(ui())
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading input: %w", err)
+		}
+
+		args := strings.Fields(input)
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "exit" {
+			fmt.Fprintln(ui(), "Exiting CLI...")
+			return nil
+		}
+
+		// A fresh tree per line so flags such as --help don't stick between commands.
+		session := newSessionCmd()
+		session.SetArgs(args)
+		if err := session.Execute(); err != nil {
+			reportError(err)
+		}
+	}
+}
+
+// newSessionCmd builds the command tree used to dispatch lines typed inside
+// an interactive session. Config is already loaded, so it has no flags of its own.
+func newSessionCmd() *cobra.Command {
+	session := &cobra.Command{
+		Use:           "perspective-taker",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd: true,
+		},
+	}
+	addOutputFlags(session)
+	addSessionCommands(session)
+	session.AddCommand(&cobra.Command{
+		Use:   "exit",
+		Short: "Exit the CLI",
+		Run:   func(cmd *cobra.Command, args []string) {},
+	})
+	return session
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"perspective_taker/config"
+	"perspective_taker/crash"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	quiet     bool
+	verbose   bool
+	debugMode bool
+
+	// recentEvents keeps the last log records, whatever the verbosity, for
+	// crash reports.
+	recentEvents = crash.NewRecorder(50)
+	logger       = slog.New(recentEvents)
+)
+
+func addLoggingFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.BoolVarP(&quiet, "quiet", "q", false, "only log errors")
+	flags.BoolVarP(&verbose, "verbose", "v", false, "log informational messages")
+	flags.BoolVar(&debugMode, "debug", false, "log everything and write a debug log to "+debugLogName)
+}
+
+const debugLogName = "debug.log"
+
+// setupLogging builds the CLI logger from the verbosity flags. Normal logs go
+// to stderr; with --debug everything is also appended to the debug log file.
+func setupLogging() error {
+	if quiet && (verbose || debugMode) {
+		return usageError{errors.New("--quiet cannot be combined with --verbose or --debug")}
+	}
+
+	level := slog.LevelWarn
+	switch {
+	case quiet:
+		level = slog.LevelError
+	case verbose || debugMode:
+		level = slog.LevelInfo
+	}
+	handlers := teeHandler{
+		slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}),
+		recentEvents,
+	}
+
+	if debugMode {
+		f, err := openDebugLog()
+		if err != nil {
+			return fmt.Errorf("opening debug log: %w", err)
+		}
+		handlers = append(handlers, slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	logger = slog.New(handlers)
+	return nil
+}
+
+func openDebugLog() (*os.File, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filepath.Join(dir, debugLogName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+}
+
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes reported by the CLI. Scripts can rely on these staying stable.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+	exitCrash = 3
+)
+
+var jsonOutput bool
+
+// addOutputFlags registers --json on cmd. The flag defaults to the current
+// value, so command trees built inside an interactive session keep the mode
+// the session was started with.
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&jsonOutput, "json", jsonOutput, "print machine-readable JSON instead of text")
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+}
+
+// ui is where prompts and other human-facing text go. In JSON mode they move
+// to stderr so stdout carries nothing but the JSON result.
+func ui() io.Writer {
+	if jsonOutput {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// usageError marks errors caused by how a command was invoked rather than by
+// the operation failing.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+
+func (e usageError) Unwrap() error { return e.err }
+
+func usageArgs(validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := validate(cmd, args); err != nil {
+			return usageError{err}
+		}
+		return nil
+	}
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if errors.As(err, &usageError{}) {
+		return exitUsage
+	}
+	return exitError
+}
+
+type errorResult struct {
+	Error    string `json:"error"`
+	ExitCode int    `json:"exit_code"`
+}
+
+func reportError(err error) {
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stderr)
+		enc.Encode(errorResult{Error: err.Error(), ExitCode: exitCode(err)})
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+}
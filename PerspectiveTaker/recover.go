@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"perspective_taker/config"
+	"perspective_taker/crash"
+)
+
+// recoverCrash turns a panic into a crash report on disk and a clean exit.
+// It must be deferred directly by main, so it only sees panics on the main
+// goroutine. The CLI runs its own code there, including the TUI's event loop.
+func recoverCrash() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	report := crash.NewReport(r, debug.Stack())
+	report.Args = config.RedactArgs(os.Args[1:])
+	report.Events = recentEvents.Events()
+	report.Config = map[string]string{}
+	for _, e := range cfg.Entries() {
+		report.Config[e[0]] = e[1]
+	}
+
+	dir, err := config.Dir()
+	if err == nil {
+		var path string
+		path, err = crash.Write(filepath.Join(dir, "crashes"), report)
+		if err == nil {
+			fmt.Fprintln(os.Stderr, "perspective-taker crashed unexpectedly. A crash report was written to", path)
+			uploadCrash(path)
+			os.Exit(exitCrash)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "perspective-taker crashed unexpectedly and could not write a crash report:", err)
+	fmt.Fprintln(os.Stderr, report.Panic)
+	fmt.Fprintln(os.Stderr, report.Stack)
+	os.Exit(exitCrash)
+}
+
+func uploadCrash(path string) {
+	if cfg.CrashUploadURL == "" {
+		fmt.Fprintln(os.Stderr, "Please attach it when reporting the problem.")
+		return
+	}
+	if err := crash.Upload(context.Background(), cfg.CrashUploadURL, path); err != nil {
+		fmt.Fprintln(os.Stderr, "Uploading the crash report failed:", err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "The crash report was uploaded. Thank you.")
+}
@@ -0,0 +1,176 @@
+package safety
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Mode decides what happens to text that triggers a policy.
+type Mode int
+
+const (
+	Off Mode = iota
+	Annotate
+	Block
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Off:
+		return "off"
+	case Annotate:
+		return "annotate"
+	case Block:
+		return "block"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "off":
+		return Off, nil
+	case "annotate":
+		return Annotate, nil
+	case "block":
+		return Block, nil
+	}
+	return Off, fmt.Errorf("unknown safety mode %q (want off, annotate or block)", s)
+}
+
+type Policy struct {
+	Name     string
+	Mode     Mode
+	Notice   string
+	Patterns []*regexp.Regexp
+}
+
+func (p *Policy) matches(text string) bool {
+	for _, re := range p.Patterns {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+func SelfHarmPolicy() *Policy {
+	return &Policy{
+		Name:   "self-harm",
+		Mode:   Block,
+		Notice: "If you are struggling, please reach out to someone you trust or a local crisis line.",
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\b(kill|hurt|harm|cut|starve|punish)(ing)? (yourself|themselves|myself)\b`),
+			regexp.MustCompile(`(?i)\b(suicide|suicidal|self[- ]harm)\b`),
+			regexp.MustCompile(`(?i)\bend (your|their|my) (own )?life\b`),
+		},
+	}
+}
+
+func MedicalAdvicePolicy() *Policy {
+	return &Policy{
+		Name:   "medical-advice",
+		Mode:   Annotate,
+		Notice: "This is not medical advice. Talk to a qualified health professional before changing treatment.",
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\b(dosage|dose|doses|\d+\s?mg|milligrams|prescriptions?|prescribed)\b`),
+			regexp.MustCompile(`(?i)\b(medications?|medicines?|supplements?|treatments?|diagnos(is|es|ed|e))\b`),
+			regexp.MustCompile(`(?i)\b(should|could|need to) (stop|start|increase|reduce) (taking|your)\b`),
+		},
+	}
+}
+
+// SetModes applies a comma-separated list of policy=mode pairs, the form
+// stored in the safety_policies option. Policies it does not name keep
+// their current mode.
+func (f *Filter) SetModes(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, modeName, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("safety policy %q has no mode (want policy=mode)", item)
+		}
+		p := f.Policy(strings.TrimSpace(name))
+		if p == nil {
+			return fmt.Errorf("unknown safety policy %q", name)
+		}
+		mode, err := ParseMode(modeName)
+		if err != nil {
+			return err
+		}
+		p.Mode = mode
+	}
+	return nil
+}
+
+// Modes lists every policy's mode in the form SetModes reads.
+func (f *Filter) Modes() string {
+	items := make([]string, len(f.Policies))
+	for i, p := range f.Policies {
+		items[i] = p.Name + "=" + p.Mode.String()
+	}
+	return strings.Join(items, ",")
+}
+
+type Verdict struct {
+	Text     string
+	Blocked  bool
+	Triggers []string
+}
+
+// Filter screens generated text (questions, perspective agent output)
+// before it is shown to the user. Every trigger is written to Audit.
+type Filter struct {
+	Policies []*Policy
+	Audit    *log.Logger
+}
+
+func NewFilter(audit *log.Logger) *Filter {
+	return &Filter{
+		Policies: []*Policy{SelfHarmPolicy(), MedicalAdvicePolicy()},
+		Audit:    audit,
+	}
+}
+
+func (f *Filter) Policy(name string) *Policy {
+	for _, p := range f.Policies {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Screen checks text from source against every enabled policy. Blocking
+// policies replace the text with their notice; annotating policies append it.
+func (f *Filter) Screen(source, text string) Verdict {
+	verdict := Verdict{Text: text}
+	var notices []string
+
+	for _, p := range f.Policies {
+		if p.Mode == Off || !p.matches(text) {
+			continue
+		}
+		verdict.Triggers = append(verdict.Triggers, p.Name)
+		if f.Audit != nil {
+			f.Audit.Printf("source=%s policy=%s mode=%s text=%q", source, p.Name, p.Mode, text)
+		}
+
+		if p.Mode == Block {
+			verdict.Blocked = true
+			verdict.Text = p.Notice
+			return verdict
+		}
+		notices = append(notices, p.Notice)
+	}
+
+	for _, n := range notices {
+		verdict.Text += "\n[Note: " + n + "]"
+	}
+	return verdict
+}
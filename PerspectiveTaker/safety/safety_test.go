@@ -0,0 +1,77 @@
+package safety
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestScreen(t *testing.T) {
+	const harmful = "Some people think about suicide when they feel this way."
+	tests := []struct {
+		mode        Mode
+		wantText    string
+		wantBlocked bool
+		wantAudit   bool
+	}{
+		{Block, SelfHarmPolicy().Notice, true, true},
+		{Annotate, harmful + "\n[Note: " + SelfHarmPolicy().Notice + "]", false, true},
+		{Off, harmful, false, false},
+	}
+	for _, tt := range tests {
+		var audit bytes.Buffer
+		f := &Filter{Policies: []*Policy{SelfHarmPolicy()}, Audit: log.New(&audit, "", 0)}
+		f.Policies[0].Mode = tt.mode
+
+		got := f.Screen("question", harmful)
+		if got.Text != tt.wantText || got.Blocked != tt.wantBlocked {
+			t.Errorf("%v: got (%q, %v), want (%q, %v)", tt.mode, got.Text, got.Blocked, tt.wantText, tt.wantBlocked)
+		}
+		if !tt.wantAudit {
+			if audit.Len() != 0 || len(got.Triggers) != 0 {
+				t.Errorf("%v: triggered %v and audited %q, want nothing", tt.mode, got.Triggers, audit.String())
+			}
+			continue
+		}
+		want := "source=question policy=self-harm mode=" + tt.mode.String() + " text="
+		if !strings.HasPrefix(audit.String(), want) || !strings.Contains(audit.String(), "suicide") {
+			t.Errorf("%v: audit record %q, want it to start with %q and quote the text", tt.mode, audit.String(), want)
+		}
+	}
+}
+
+func TestScreenClean(t *testing.T) {
+	f := NewFilter(nil)
+	got := f.Screen("agent", "What do you remember about your childhood?")
+	if got.Text != "What do you remember about your childhood?" || got.Blocked || len(got.Triggers) != 0 {
+		t.Errorf("got %+v, want the text unchanged", got)
+	}
+}
+
+func TestAnnotateCombinesNotices(t *testing.T) {
+	f := NewFilter(nil)
+	f.Policy("self-harm").Mode = Annotate
+	got := f.Screen("question", "Would changing your medication dose affect thoughts of self-harm?")
+	if len(got.Triggers) != 2 || strings.Count(got.Text, "[Note: ") != 2 {
+		t.Errorf("got %+v, want both policies to annotate", got)
+	}
+}
+
+func TestSetModes(t *testing.T) {
+	f := NewFilter(nil)
+	if err := f.SetModes(" self-harm=annotate, medical-advice=OFF "); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Modes(); got != "self-harm=annotate,medical-advice=off" {
+		t.Errorf("Modes() = %q", got)
+	}
+	if err := f.SetModes(""); err != nil || f.Modes() != "self-harm=annotate,medical-advice=off" {
+		t.Errorf("empty spec changed the modes to %q (%v)", f.Modes(), err)
+	}
+	for _, spec := range []string{"violence=block", "self-harm", "self-harm=loud"} {
+		if err := NewFilter(nil).SetModes(spec); err == nil {
+			t.Errorf("SetModes(%q) succeeded, want an error", spec)
+		}
+	}
+}
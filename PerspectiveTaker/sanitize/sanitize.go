@@ -0,0 +1,115 @@
+package sanitize
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Policy decides what happens to input that matches an injection pattern.
+type Policy int
+
+const (
+	Flag Policy = iota
+	Strip
+	Block
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Flag:
+		return "flag"
+	case Strip:
+		return "strip"
+	case Block:
+		return "block"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+func ParsePolicy(s string) (Policy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "flag":
+		return Flag, nil
+	case "strip":
+		return Strip, nil
+	case "block":
+		return Block, nil
+	}
+	return Flag, fmt.Errorf("unknown sanitize policy %q (want flag, strip or block)", s)
+}
+
+type pattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// Known injection patterns. Answers and imported text end up inside LLM
+// prompts, so anything that tries to address the model directly is suspect.
+var defaultPatterns = []pattern{
+	// Only instructions aimed at the model count, so that answers such as
+	// "I ignore the rules when they feel unfair" pass.
+	{"ignore-instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b[^.\n]{0,30}\b((previous|prior|above|earlier|preceding)\b[^.\n]{0,15}\b(instructions?|prompts?|directions)|your\b[^.\n]{0,15}\b(instructions?|prompts?|rules|guidelines|programming))\b`)},
+	{"forget-everything", regexp.MustCompile(`(?i)\bforget (everything|all)( that)? you('ve| have| were)?\b`)},
+	// A role or instruction word has to follow, so that answers such as "you
+	// are no longer the same person" pass.
+	{"role-override", regexp.MustCompile(`(?i)\byou are (now|no longer)\b[^.\n<]{0,30}?\b(assistants?|ai|models?|chatbots?|bots?|dan|llms?|gpt|persona|character|bound by)\b[^.\n<]{0,60}`)},
+	{"system-prompt", regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output)\b[^.\n]{0,20}\b(system|hidden|initial) (prompt|instructions?|message)\b`)},
+	{"new-instructions", regexp.MustCompile(`(?i)\bnew (instructions?|rules)\s*:`)},
+	{"developer-mode", regexp.MustCompile(`(?i)\b(developer|dan|jailbreak|god) mode\b`)},
+	{"role-tag", regexp.MustCompile(`(?i)</?\s*(system|assistant|user|instructions?)\s*>`)},
+	{"chat-template", regexp.MustCompile(`(?i)(\[/?INST\]|<<\s*/?SYS\s*>>|<\|im_(start|end)\|>|<\|(system|assistant|user)\|>)`)},
+	{"markdown-header", regexp.MustCompile(`(?im)^\s*#{2,}\s*(system|instruction|instructions|assistant)\b.*$`)},
+}
+
+// Zero-width and bidi control characters are used to hide instructions from
+// the person reading the text while leaving them visible to the model.
+var hiddenChars = regexp.MustCompile(`[\x{200B}-\x{200F}\x{202A}-\x{202E}\x{2060}-\x{2064}\x{FEFF}]`)
+
+type Result struct {
+	Text    string
+	Matches []string
+	Blocked bool
+}
+
+func (r Result) Flagged() bool {
+	return len(r.Matches) > 0
+}
+
+type Sanitizer struct {
+	Policy   Policy
+	patterns []pattern
+}
+
+func New(policy Policy) *Sanitizer {
+	return &Sanitizer{Policy: policy, patterns: defaultPatterns}
+}
+
+// Check scans input for injection patterns and applies the sanitizer's policy.
+// Hidden control characters are always removed, whatever the policy.
+func (s *Sanitizer) Check(input string) Result {
+	text := hiddenChars.ReplaceAllString(input, "")
+	result := Result{Text: text}
+
+	for _, p := range s.patterns {
+		if !p.re.MatchString(text) {
+			continue
+		}
+		result.Matches = append(result.Matches, p.name)
+		if s.Policy == Strip {
+			result.Text = p.re.ReplaceAllString(result.Text, "")
+		}
+	}
+
+	if !result.Flagged() {
+		return result
+	}
+	switch s.Policy {
+	case Strip:
+		result.Text = strings.Join(strings.Fields(result.Text), " ")
+	case Block:
+		result.Blocked = true
+		result.Text = ""
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"perspective_taker/scenario"
+
+	"github.com/spf13/cobra"
+)
+
+func scenarioCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "scenario <file.yaml>",
+		Short: "Play through a branching role-play scenario and reflect on your choices",
+		Args:  usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := scenario.Load(args[0])
+			if err != nil {
+				return usageError{err}
+			}
+			return runScenario(s)
+		},
+	}
+}
+
+type scenarioStep struct {
+	Scene  string `json:"scene"`
+	Choice string `json:"choice,omitempty"`
+	Probe  string `json:"probe,omitempty"`
+	Answer string `json:"answer,omitempty"`
+}
+
+type scenarioRun struct {
+	Title string         `json:"title"`
+	Steps []scenarioStep `json:"steps"`
+}
+
+func runScenario(s *scenario.Scenario) error {
+	out := ui()
+	run := scenarioRun{Title: s.Title, Steps: []scenarioStep{}}
+
+	fmt.Fprintln(out, s.Title)
+	if s.Intro != "" {
+		fmt.Fprintln(out, s.Intro)
+	}
+
+	name := s.Start
+	for {
+		scene := s.Scenes[name]
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, scene.Text)
+		if scene.IsEnding() {
+			run.Steps = append(run.Steps, scenarioStep{Scene: name})
+			break
+		}
+
+		for i, c := range scene.Choices {
+			fmt.Fprintf(out, "  %d) %s\n", i+1, c.Label)
+		}
+		choice, err := readChoice(len(scene.Choices))
+		if err != nil {
+			return err
+		}
+		c := scene.Choices[choice]
+		logger.Debug("scenario choice", "scene", name, "choice", choice+1)
+
+		step := scenarioStep{Scene: name, Choice: c.Label}
+		if c.Probe != "" {
+			step.Probe = askQuestion(c.Probe)
+			if step.Answer, err = readResponse("Your answer: "); err != nil {
+				return err
+			}
+		}
+		run.Steps = append(run.Steps, step)
+		name = c.Next
+	}
+
+	if jsonOutput {
+		return printJSON(run)
+	}
+	fmt.Println()
+	fmt.Println("Scenario complete.")
+	return nil
+}
+
+func readChoice(n int) (int, error) {
+	for {
+		fmt.Fprintf(ui(), "Choose 1-%d: ", n)
+		input, err := stdin.ReadString('\n')
+		if err != nil {
+			return 0, fmt.Errorf("reading choice: %w", err)
+		}
+		choice, err := strconv.Atoi(strings.TrimSpace(input))
+		if err == nil && choice >= 1 && choice <= n {
+			return choice - 1, nil
+		}
+		fmt.Fprintln(ui(), "Please enter the number of one of the choices.")
+	}
+}
@@ -0,0 +1,118 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a branching role-play script. The user moves from scene to
+// scene by picking choices; a choice may carry a probe question that the
+// dialectic asks to surface the belief behind the choice.
+type Scenario struct {
+	Title  string            `yaml:"title"`
+	Intro  string            `yaml:"intro"`
+	Start  string            `yaml:"start"`
+	Scenes map[string]*Scene `yaml:"scenes"`
+}
+
+// Scene is one step of a scenario. A scene without choices ends the scenario.
+type Scene struct {
+	Text    string   `yaml:"text"`
+	Choices []Choice `yaml:"choices"`
+}
+
+type Choice struct {
+	Label string `yaml:"label"`
+	Next  string `yaml:"next"`
+	Probe string `yaml:"probe"`
+}
+
+func (s *Scene) IsEnding() bool {
+	return len(s.Choices) == 0
+}
+
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func Parse(data []byte) (*Scenario, error) {
+	var s Scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate checks that the start scene and every choice target exist and
+// that every scene can be reached from the start.
+func (s *Scenario) Validate() error {
+	if len(s.Scenes) == 0 {
+		return errors.New("scenario has no scenes")
+	}
+	if _, ok := s.Scenes[s.Start]; !ok {
+		return fmt.Errorf("start scene %q does not exist", s.Start)
+	}
+
+	var errs []error
+	for _, name := range s.sceneNames() {
+		scene := s.Scenes[name]
+		if scene == nil || scene.Text == "" {
+			errs = append(errs, fmt.Errorf("scene %q has no text", name))
+			continue
+		}
+		for i, c := range scene.Choices {
+			if c.Label == "" {
+				errs = append(errs, fmt.Errorf("scene %q choice %d has no label", name, i+1))
+			}
+			if _, ok := s.Scenes[c.Next]; !ok {
+				errs = append(errs, fmt.Errorf("scene %q choice %d leads to unknown scene %q", name, i+1, c.Next))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	reached := map[string]bool{}
+	s.walk(s.Start, reached)
+	for _, name := range s.sceneNames() {
+		if !reached[name] {
+			errs = append(errs, fmt.Errorf("scene %q is unreachable from %q", name, s.Start))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Scenario) walk(name string, reached map[string]bool) {
+	if reached[name] {
+		return
+	}
+	reached[name] = true
+	for _, c := range s.Scenes[name].Choices {
+		s.walk(c.Next, reached)
+	}
+}
+
+func (s *Scenario) sceneNames() []string {
+	names := make([]string, 0, len(s.Scenes))
+	for name := range s.Scenes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,101 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+)
+
+const valid = `
+title: Test
+start: a
+scenes:
+  a:
+    text: You find a wallet.
+    choices:
+      - label: Keep it
+        next: end
+        probe: Why is keeping it acceptable?
+      - label: Hand it in
+        next: end
+  end:
+    text: The day goes on.
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := s.Scenes[s.Start]
+	if len(start.Choices) != 2 || start.Choices[0].Probe == "" || start.IsEnding() || !s.Scenes["end"].IsEnding() {
+		t.Errorf("parsed %+v", s)
+	}
+}
+
+func TestParseExample(t *testing.T) {
+	if _, err := Load("../scenarios/whistleblower.yaml"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"no scenes", "start: a\n", "has no scenes"},
+		{"missing start", `
+scenes:
+  a:
+    text: A
+`, `start scene "" does not exist`},
+		{"unknown start", `
+start: b
+scenes:
+  a:
+    text: A
+`, `start scene "b" does not exist`},
+		{"unknown next", `
+start: a
+scenes:
+  a:
+    text: A
+    choices:
+      - label: Go
+        next: nowhere
+`, `scene "a" choice 1 leads to unknown scene "nowhere"`},
+		{"empty scene", `
+start: a
+scenes:
+  a:
+    text: A
+    choices:
+      - label: Go
+        next: b
+  b:
+`, `scene "b" has no text`},
+		{"unlabelled choice", `
+start: a
+scenes:
+  a:
+    text: A
+    choices:
+      - next: a
+`, `scene "a" choice 1 has no label`},
+		{"unreachable scene", `
+start: a
+scenes:
+  a:
+    text: A
+  orphan:
+    text: Nobody gets here.
+`, `scene "orphan" is unreachable from "a"`},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.yaml))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: got %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
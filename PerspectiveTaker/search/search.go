@@ -0,0 +1,407 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Query is a parsed full-text query. The syntax follows SQLite FTS5:
+//
+//	memory identity        both words (implicit AND)
+//	"personal identity"    the exact phrase
+//	memory OR continuity   either word
+//	identity NOT memory    identity without memory (-memory works too)
+//	ident*                 any word starting with ident
+//	(a OR b) c             grouping
+//
+// Matching is case-insensitive and works on whole words.
+type Query struct {
+	root node
+}
+
+// Parse parses an exact query: every word has to appear as written.
+func Parse(q string) (*Query, error) {
+	return parse(q, false)
+}
+
+// ParseForms parses a query whose unquoted words also match longer forms of
+// themselves, so "remember" finds "remembered". Words shorter than
+// minStem letters and quoted phrases still match exactly.
+func ParseForms(q string) (*Query, error) {
+	return parse(q, true)
+}
+
+const minStem = 4
+
+func parse(q string, forms bool) (*Query, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty query")
+	}
+	p := &parser{tokens: tokens, forms: forms}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return &Query{root: root}, nil
+}
+
+// Match reports whether text satisfies the query.
+func (q *Query) Match(text string) bool {
+	return q.root.match(tokenize(text))
+}
+
+// Highlight returns a snippet of text around the first match, with every
+// matching phrase wrapped in open and close. It returns "" if nothing in text
+// matches a positive term of the query.
+func (q *Query) Highlight(text, open, close string, width int) string {
+	snippet, spans := q.Snippet(text, width)
+	if len(spans) == 0 {
+		return ""
+	}
+	return Mark(snippet, spans, open, close)
+}
+
+// Mark wraps the given byte ranges of text, as returned by Snippet, in open
+// and close.
+func Mark(text string, spans [][2]int, open, close string) string {
+	var b strings.Builder
+	pos := 0
+	for _, sp := range spans {
+		b.WriteString(text[pos:sp[0]])
+		b.WriteString(open)
+		b.WriteString(text[sp[0]:sp[1]])
+		b.WriteString(close)
+		pos = sp[1]
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// Snippet returns about width bytes of text around the first match, with
+// whitespace collapsed and "…" marking cut ends, and the byte ranges of the
+// matching phrases within it. If nothing in text matches a positive term of
+// the query, as with a purely negative query, the snippet is the start of
+// text and there are no ranges.
+func (q *Query) Snippet(text string, width int) (string, [][2]int) {
+	text = strings.Join(strings.Fields(text), " ")
+	words := tokenize(text)
+	var spans [][2]int
+	for _, ph := range q.root.phrases(nil) {
+		for i := range words {
+			if ph.matchAt(words, i) {
+				spans = append(spans, [2]int{words[i].start, words[i+len(ph.words)-1].end})
+			}
+		}
+	}
+	if len(spans) > 0 {
+		spans = mergeSpans(spans)
+	}
+
+	from, to := 0, len(text)
+	if width > 0 && len(text) > width {
+		if len(spans) > 0 {
+			from = max(0, spans[0][0]-width/3)
+		}
+		to = min(len(text), from+width)
+		from = wordBoundary(text, from, -1)
+		to = wordBoundary(text, to, 1)
+		if from > 0 {
+			from++ // skip the space itself
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("… ")
+	}
+	offset := b.Len() - from
+	b.WriteString(text[from:to])
+	if to < len(text) {
+		b.WriteString(" …")
+	}
+	inside := [][2]int{}
+	for _, sp := range spans {
+		if sp[1] <= from || sp[0] >= to {
+			continue
+		}
+		inside = append(inside, [2]int{max(sp[0], from) + offset, min(sp[1], to) + offset})
+	}
+	return b.String(), inside
+}
+
+func wordBoundary(text string, i, dir int) int {
+	for i > 0 && i < len(text) && text[i] != ' ' {
+		i += dir
+	}
+	return i
+}
+
+func mergeSpans(spans [][2]int) [][2]int {
+	for i := 1; i < len(spans); i++ {
+		for j := i; j > 0 && spans[j][0] < spans[j-1][0]; j-- {
+			spans[j], spans[j-1] = spans[j-1], spans[j]
+		}
+	}
+	merged := spans[:1]
+	for _, sp := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if sp[0] <= last[1] {
+			last[1] = max(last[1], sp[1])
+			continue
+		}
+		merged = append(merged, sp)
+	}
+	return merged
+}
+
+type word struct {
+	text       string
+	start, end int
+}
+
+func tokenize(text string) []word {
+	var words []word
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsNumber(r) || r == '\''
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			words = append(words, word{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, word{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return words
+}
+
+type node interface {
+	match(words []word) bool
+	// phrases collects the positive phrases, used for highlighting.
+	phrases(acc []phrase) []phrase
+}
+
+type phrase struct {
+	words  []string
+	prefix bool
+}
+
+func (p phrase) matchAt(words []word, i int) bool {
+	if i+len(p.words) > len(words) {
+		return false
+	}
+	for j, w := range p.words {
+		got := words[i+j].text
+		if p.prefix && j == len(p.words)-1 {
+			if !strings.HasPrefix(got, w) {
+				return false
+			}
+		} else if got != w {
+			return false
+		}
+	}
+	return true
+}
+
+func (p phrase) match(words []word) bool {
+	for i := range words {
+		if p.matchAt(words, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p phrase) phrases(acc []phrase) []phrase { return append(acc, p) }
+
+type and []node
+
+func (n and) match(words []word) bool {
+	for _, c := range n {
+		if !c.match(words) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n and) phrases(acc []phrase) []phrase {
+	for _, c := range n {
+		acc = c.phrases(acc)
+	}
+	return acc
+}
+
+type or []node
+
+func (n or) match(words []word) bool {
+	for _, c := range n {
+		if c.match(words) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n or) phrases(acc []phrase) []phrase {
+	for _, c := range n {
+		acc = c.phrases(acc)
+	}
+	return acc
+}
+
+type not struct{ node }
+
+func (n not) match(words []word) bool { return !n.node.match(words) }
+
+func (n not) phrases(acc []phrase) []phrase { return acc }
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+func lex(q string) ([]token, error) {
+	var tokens []token
+	rs := []rune(q)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, token{text: string(r)})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(rs) && rs[end] != '"' {
+				end++
+			}
+			if end == len(rs) {
+				return nil, errors.New("unterminated phrase")
+			}
+			tokens = append(tokens, token{text: string(rs[i+1 : end]), quoted: true})
+			i = end + 1
+		case r == '-' && (i == 0 || unicode.IsSpace(rs[i-1]) || rs[i-1] == '('):
+			tokens = append(tokens, token{text: "NOT"})
+			i++
+		default:
+			end := i
+			for end < len(rs) && !unicode.IsSpace(rs[end]) && rs[end] != '(' && rs[end] != ')' && rs[end] != '"' {
+				end++
+			}
+			tokens = append(tokens, token{text: string(rs[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	forms  bool
+}
+
+func (p *parser) peek(op string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && p.tokens[p.pos].text == op
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	nodes := or{left}
+	for p.peek("OR") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return nodes, nil
+}
+
+func (p *parser) and() (node, error) {
+	var nodes and
+	for p.pos < len(p.tokens) && !p.peek("OR") && !p.peek(")") {
+		if p.peek("AND") {
+			p.pos++
+			continue
+		}
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	switch len(nodes) {
+	case 0:
+		return nil, errors.New("expected a search term")
+	case 1:
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.peek("NOT") {
+		p.pos++
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{n}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("expected a search term")
+	}
+	if p.peek("(") {
+		p.pos++
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return n, nil
+	}
+
+	t := p.tokens[p.pos]
+	p.pos++
+	text, prefix := strings.CutSuffix(t.text, "*")
+	var ws []string
+	for _, w := range tokenize(text) {
+		ws = append(ws, w.text)
+	}
+	if len(ws) == 0 {
+		return nil, fmt.Errorf("%q has no words to search for", t.text)
+	}
+	if p.forms && !t.quoted && len(ws) == 1 && len([]rune(ws[0])) >= minStem {
+		prefix = true
+	}
+	return phrase{words: ws, prefix: prefix}, nil
+}
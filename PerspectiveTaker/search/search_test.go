@@ -0,0 +1,110 @@
+package search
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	const text = "Personal identity rests on memory, not on the body I happen to have."
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"memory", true},
+		{"MEMORY identity", true},
+		{"memory soul", false},
+		{`"personal identity"`, true},
+		{`"identity personal"`, false},
+		{"soul OR body", true},
+		{"soul OR spirit", false},
+		{"identity NOT body", false},
+		{"identity -soul", true},
+		{"ident*", true},
+		{"iden", false},
+		{"(soul OR memory) personal", true},
+		{"(soul OR spirit) personal", false},
+		{"memory AND body", true},
+		{"mem", false},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		if got := q.Match(text); got != tt.want {
+			t.Errorf("%q matched = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseForms(t *testing.T) {
+	const text = "I remembered changing my mind."
+	tests := []struct {
+		query        string
+		exact, forms bool
+	}{
+		{"remember", false, true},
+		{"change", false, false},
+		{"chang", false, true},
+		{`"remember"`, false, false},
+		{"mind", true, true},
+		{"my", true, true},
+		{"min", false, false},
+	}
+	for _, tt := range tests {
+		exact, _ := Parse(tt.query)
+		forms, _ := ParseForms(tt.query)
+		if got := exact.Match(text); got != tt.exact {
+			t.Errorf("Parse(%q) matched = %v, want %v", tt.query, got, tt.exact)
+		}
+		if got := forms.Match(text); got != tt.forms {
+			t.Errorf("ParseForms(%q) matched = %v, want %v", tt.query, got, tt.forms)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, q := range []string{"", "   ", `"unterminated`, "(memory", "memory)", "memory OR", "NOT", "()", "***"} {
+		if _, err := Parse(q); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", q)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		query, text string
+		width       int
+		want        string
+	}{
+		{"memory", "Memory makes me who I am", 0, "[Memory] makes me who I am"},
+		{`"who I am"`, "Memory makes me who I am", 0, "Memory makes me [who I am]"},
+		{"me*", "Memory makes me who I am", 0, "[Memory] makes [me] who I am"},
+		{"body -memory", "the body", 0, "the [body]"},
+		{"soul", "Memory makes me who I am", 0, ""},
+		{"body", "I think my   memory and\nmy body both matter to who I am over the years", 30,
+			"… memory and my [body] both matter to who …"},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := q.Highlight(tt.text, "[", "]", tt.width); got != tt.want {
+			t.Errorf("Highlight(%q, %q) = %q, want %q", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSnippetRanges(t *testing.T) {
+	q, _ := Parse("body")
+	snippet, spans := q.Snippet("I think my memory and my body both matter to who I am over the years", 30)
+	if len(spans) != 1 || snippet[spans[0][0]:spans[0][1]] != "body" {
+		t.Errorf("Snippet = %q, %v; want a range covering body", snippet, spans)
+	}
+
+	q, _ = Parse("NOT soul")
+	snippet, spans = q.Snippet("a long answer about memory and the body over many years", 20)
+	if snippet != "a long answer about memory …" || len(spans) != 0 {
+		t.Errorf("negative query: Snippet = %q, %v; want the start of the text", snippet, spans)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"perspective_taker/search"
+	"perspective_taker/terminal"
+
+	"github.com/spf13/cobra"
+)
+
+func searchCmd() *cobra.Command {
+	var exact bool
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search the transcripts of saved sessions",
+		Long: `Search the questions and answers of saved sessions.
+
+Words must all appear (AND is implied). Quote a phrase to match it exactly,
+use OR for alternatives, NOT or a leading - to exclude a word, a trailing *
+to match a prefix and parentheses to group:
+
+  perspective-taker search '"personal identity" (memory OR continuity) -body'
+
+Words of four or more letters also match longer forms of themselves, so
+"remember" finds "remembered". Use --exact to match whole words only.`,
+		Args: usageArgs(cobra.MinimumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			parse := search.ParseForms
+			if exact {
+				parse = search.Parse
+			}
+			query, err := parse(strings.Join(args, " "))
+			if err != nil {
+				return usageError{fmt.Errorf("invalid query: %w", err)}
+			}
+			return searchSessions(query)
+		},
+	}
+	cmd.Flags().BoolVar(&exact, "exact", false, "match whole words only, not longer forms of them")
+	return cmd
+}
+
+type searchHit struct {
+	Session string    `json:"session"`
+	Turn    int       `json:"turn"`
+	Source  string    `json:"source"`
+	Time    time.Time `json:"time"`
+	// Field is the part of the turn that matched: question or answer.
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+	// Matches are the byte ranges of the matching words in Snippet.
+	Matches [][2]int `json:"matches"`
+}
+
+type searchResults struct {
+	Hits []searchHit `json:"hits"`
+}
+
+const snippetWidth = 120
+
+func searchSessions(query *search.Query) error {
+	sessions, err := openStore()
+	if err != nil {
+		return err
+	}
+	saved, err := sessions.List()
+	if err != nil {
+		return err
+	}
+
+	result := searchResults{Hits: []searchHit{}}
+	for _, s := range saved {
+		for i, t := range s.Turns {
+			// Each field is matched on its own so that a phrase or a set of
+			// words can't be satisfied across the question and the answer.
+			field, text := "answer", t.Answer
+			if !query.Match(text) {
+				field, text = "question", t.Question
+				if !query.Match(text) {
+					continue
+				}
+			}
+			snippet, matches := query.Snippet(text, snippetWidth)
+			result.Hits = append(result.Hits, searchHit{
+				Session: s.ID,
+				Turn:    i + 1,
+				Source:  t.Source,
+				Time:    t.Time,
+				Field:   field,
+				Snippet: snippet,
+				Matches: matches,
+			})
+		}
+	}
+
+	if jsonOutput {
+		return printJSON(result)
+	}
+	if len(result.Hits) == 0 {
+		fmt.Println("No matches.")
+		return nil
+	}
+	open, close := "**", "**"
+	if terminal.EnableANSI(os.Stdout) {
+		open, close = "\033[1m", "\033[0m"
+	}
+	for _, h := range result.Hits {
+		fmt.Printf("%s #%-2d  %s\n", h.Session, h.Turn, search.Mark(h.Snippet, h.Matches, open, close))
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"perspective_taker/conductor"
+	"perspective_taker/config"
+	"perspective_taker/store"
+
+	"github.com/spf13/cobra"
+)
+
+func openStore() (*store.Store, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return store.Open(dir), nil
+}
+
+func sessionsCmd() *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "List saved dialogue sessions that can be resumed",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listSessions(all)
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "include sessions that have ended")
+	return cmd
+}
+
+type sessionInfo struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Conductor string    `json:"conductor"`
+	Answers   int       `json:"answers"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type sessionList struct {
+	Sessions []sessionInfo `json:"sessions"`
+}
+
+func infoOf(s store.Session) sessionInfo {
+	answers := 0
+	for _, t := range s.Turns {
+		if t.Answer != "" && t.Source != conductor.UserAsk.String() {
+			answers++
+		}
+	}
+	return sessionInfo{
+		ID:        s.ID,
+		Status:    s.Status,
+		Conductor: s.Conductor,
+		Answers:   answers,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func listSessions(all bool) error {
+	sessions, err := openStore()
+	if err != nil {
+		return err
+	}
+	saved, err := sessions.List()
+	if err != nil {
+		return err
+	}
+
+	result := sessionList{Sessions: []sessionInfo{}}
+	for _, s := range saved {
+		if all || s.Status == store.StatusOpen {
+			result.Sessions = append(result.Sessions, infoOf(s))
+		}
+	}
+
+	if jsonOutput {
+		return printJSON(result)
+	}
+	if len(result.Sessions) == 0 {
+		fmt.Println("No sessions to resume. Start one with 'dialogue'.")
+		return nil
+	}
+	for _, s := range result.Sessions {
+		fmt.Printf("%s  %-5s  %-6s  %2d answers  last active %s\n",
+			s.ID, s.Status, s.Conductor, s.Answers, s.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func resumeCmd() *cobra.Command {
+	var tui bool
+	cmd := &cobra.Command{
+		Use:   "resume <sessionID>",
+		Short: "Continue a dialogue session where you left off",
+		Args:  usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sessions, err := openStore()
+			if err != nil {
+				return err
+			}
+			record, err := sessions.Get(args[0])
+			if errors.Is(err, store.ErrNotFound) {
+				return usageError{err}
+			}
+			if err != nil {
+				return err
+			}
+			if record.Status != store.StatusOpen {
+				return usageError{fmt.Errorf("session %s has already ended", record.ID)}
+			}
+
+			session, err := newSession(record)
+			if err != nil {
+				return err
+			}
+			if tui {
+				return runDialogueTUI(cmd.Context(), session, &record)
+			}
+			return runDialogue(cmd.Context(), session, &record)
+		},
+	}
+	cmd.Flags().BoolVar(&tui, "tui", false, "run the dialogue in a full-screen terminal UI")
+	return cmd
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"perspective_taker/simulator"
+	"perspective_taker/store"
+
+	"github.com/spf13/cobra"
+)
+
+func simulateCmd() *cobra.Command {
+	var (
+		conductorName string
+		answers       int
+		seed          int64
+		traits        = simulator.Traits{Verbosity: 0.3, Consistency: 0.7, Knowledge: 0.8, Contrarianism: 0.1}
+	)
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Run a dialogue with a simulated user and print the transcript",
+		Long: `Run a dialogue with a simulated user and print the transcript. The session
+is not saved. Use a fixed --seed to get the same dialogue every time.`,
+		Args: usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if answers <= 0 {
+				return usageError{errors.New("--answers must be at least 1")}
+			}
+			if err := traits.Validate(); err != nil {
+				return usageError{err}
+			}
+
+			record := store.NewSession(conductorName, answers)
+			session, err := newSession(record)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("seed") {
+				seed = time.Now().UnixNano()
+			}
+			user := simulator.New(traits, simulator.IdentityBank, seed)
+			start := time.Now()
+			if err := simulator.Run(cmd.Context(), session, user, answers); err != nil {
+				return err
+			}
+			logger.Info("simulation finished", "answers", session.State.Answered(), "elapsed", time.Since(start))
+
+			record.Status = store.StatusEnded
+			syncTurns(&record, session)
+			t := transcriptOf(record)
+			if jsonOutput {
+				return printJSON(t)
+			}
+			for _, turn := range t.Turns {
+				fmt.Printf("[%s] %s\n", turn.Source, turn.Question)
+				if turn.Answer != "" {
+					fmt.Printf("  > %s\n", turn.Answer)
+				}
+			}
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&conductorName, "conductor", "server", "questioning policy: server or hybrid")
+	flags.IntVar(&answers, "answers", 5, "number of questions the simulated user answers")
+	flags.Int64Var(&seed, "seed", 0, "random seed for the simulated user (random when not set)")
+	flags.Float64Var(&traits.Verbosity, "verbosity", traits.Verbosity, "how much the user elaborates (0-1)")
+	flags.Float64Var(&traits.Consistency, "consistency", traits.Consistency, "how likely the user keeps their earlier position (0-1)")
+	flags.Float64Var(&traits.Knowledge, "knowledge", traits.Knowledge, "how likely the user has a view at all (0-1)")
+	flags.Float64Var(&traits.Contrarianism, "contrarianism", traits.Contrarianism, "how likely the user pushes back on a question (0-1)")
+	return cmd
+}
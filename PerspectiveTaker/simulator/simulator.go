@@ -0,0 +1,158 @@
+// Package simulator answers dialogue questions automatically so the whole
+// dialogue loop can be exercised without a person at the keyboard.
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"perspective_taker/conductor"
+)
+
+// Answerer produces a user's answer to a question. UserSimulator answers
+// from canned banks; an LLM-backed Answerer can be swapped in.
+type Answerer interface {
+	Answer(ctx context.Context, s conductor.State, question string) (string, error)
+}
+
+// Traits shape a simulated user. Each is a probability or weight from 0 to 1.
+type Traits struct {
+	// Verbosity is how much the user elaborates on an answer.
+	Verbosity float64
+	// Consistency is how likely the user is to keep their earlier position.
+	Consistency float64
+	// Knowledge is how likely the user is to have a view at all.
+	Knowledge float64
+	// Contrarianism is how likely the user is to push back on a question.
+	Contrarianism float64
+}
+
+func (t Traits) Validate() error {
+	for _, trait := range []struct {
+		name  string
+		value float64
+	}{
+		{"verbosity", t.Verbosity},
+		{"consistency", t.Consistency},
+		{"knowledge", t.Knowledge},
+		{"contrarianism", t.Contrarianism},
+	} {
+		if trait.value < 0 || trait.value > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", trait.name, trait.value)
+		}
+	}
+	return nil
+}
+
+// Bank is the canned material a UserSimulator builds answers from.
+// Reaffirm entries take the earlier position as a %s argument.
+type Bank struct {
+	Positions    []string
+	Elaborations []string
+	Reaffirm     []string
+	Contrary     []string
+	Unsure       []string
+}
+
+// IdentityBank covers the personal identity questions the CLI asks by default.
+var IdentityBank = Bank{
+	Positions: []string{
+		"I think I'm the same person because my memories connect who I was to who I am.",
+		"I think identity is mostly about character; my values matter more than my memories.",
+		"I think identity is tied to my body; whatever else changes, it's still me living this life.",
+		"I think there isn't a fixed self at all, just a story I keep telling about myself.",
+	},
+	Elaborations: []string{
+		"When I look at old photos I recognise myself, even though I think differently now.",
+		"Friends who knew me as a child would still say I'm the same person.",
+		"Some of my beliefs have changed completely, and that didn't feel like becoming someone else.",
+		"If I lost all my memories I'm not sure what would be left of me.",
+	},
+	Reaffirm: []string{
+		"As I said before: %s",
+		"I keep coming back to the same idea. %s",
+	},
+	Contrary: []string{
+		"I'm not sure the question is framed the right way.",
+		"I'd push back on that a little.",
+	},
+	Unsure: []string{
+		"I honestly haven't thought about that before.",
+		"I don't know, I'd have to think about it.",
+	},
+}
+
+// UserSimulator answers from a Bank according to its Traits. Rand makes
+// runs reproducible; seed it for regression tests.
+type UserSimulator struct {
+	Traits Traits
+	Bank   Bank
+	Rand   *rand.Rand
+
+	position string
+}
+
+func New(traits Traits, bank Bank, seed int64) *UserSimulator {
+	return &UserSimulator{Traits: traits, Bank: bank, Rand: rand.New(rand.NewSource(seed))}
+}
+
+func (u *UserSimulator) Answer(ctx context.Context, s conductor.State, question string) (string, error) {
+	if len(u.Bank.Positions) == 0 {
+		return "", errors.New("simulator: bank has no positions")
+	}
+	if u.Rand.Float64() >= u.Traits.Knowledge && len(u.Bank.Unsure) > 0 {
+		return u.pick(u.Bank.Unsure), nil
+	}
+
+	var parts []string
+	if u.Rand.Float64() < u.Traits.Contrarianism && len(u.Bank.Contrary) > 0 {
+		parts = append(parts, u.pick(u.Bank.Contrary))
+	}
+	if u.position != "" && u.Rand.Float64() < u.Traits.Consistency {
+		if len(u.Bank.Reaffirm) > 0 {
+			parts = append(parts, fmt.Sprintf(u.pick(u.Bank.Reaffirm), u.position))
+		} else {
+			parts = append(parts, u.position)
+		}
+	} else {
+		u.position = u.pick(u.Bank.Positions)
+		parts = append(parts, u.position)
+	}
+	for n := int(u.Traits.Verbosity*3 + 0.5); n > 0 && len(u.Bank.Elaborations) > 0; n-- {
+		parts = append(parts, u.pick(u.Bank.Elaborations))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func (u *UserSimulator) pick(options []string) string {
+	return options[u.Rand.Intn(len(options))]
+}
+
+// Run plays a session to the end, answering every question with a. It stops
+// after maxAnswers answers if the conductor has not ended the session by
+// then; with 0 it only stops when the conductor does.
+func Run(ctx context.Context, session *conductor.Session, a Answerer, maxAnswers int) error {
+	for maxAnswers <= 0 || session.State.Answered() < maxAnswers {
+		t, err := session.Next(ctx)
+		if err != nil {
+			return err
+		}
+		switch t.Action {
+		case conductor.End:
+			return nil
+		case conductor.Summarize:
+			continue
+		}
+		answer, err := a.Answer(ctx, session.State, t.Question)
+		if err != nil {
+			return fmt.Errorf("simulator: %w", err)
+		}
+		if err := session.Answer(answer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
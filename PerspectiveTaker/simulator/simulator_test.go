@@ -0,0 +1,122 @@
+package simulator
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	"perspective_taker/conductor"
+)
+
+func newSession(c conductor.Conductor) *conductor.Session {
+	return &conductor.Session{
+		Conductor: c,
+		Server: conductor.Novel{
+			Source: &conductor.Script{Questions: []string{
+				"What are your initial thoughts on the concept of personal identity?",
+				"How does this relate to the continuity or change over time?",
+				"Would you still be you without your memories?",
+			}},
+			Fallback:  conductor.FollowUp{},
+			Threshold: 0.8,
+			Retries:   2,
+		},
+		Local:      conductor.FollowUp{},
+		Summarizer: conductor.Recap{},
+	}
+}
+
+var traits = Traits{Verbosity: 0.5, Consistency: 0.5, Knowledge: 0.9, Contrarianism: 0.2}
+
+func TestRunConductors(t *testing.T) {
+	tests := []struct {
+		name      string
+		conductor conductor.Conductor
+		want      []conductor.Action
+	}{
+		{"server", conductor.ServerOnly{MaxTurns: 8}, []conductor.Action{conductor.AskServer}},
+		{"hybrid", conductor.Hybrid{LocalEvery: 3, SummarizeEvery: 5, MaxTurns: 8}, []conductor.Action{conductor.AskServer, conductor.AskLocal, conductor.Summarize}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := func() conductor.State {
+				session := newSession(tt.conductor)
+				if err := Run(context.Background(), session, New(traits, IdentityBank, 7), 0); err != nil {
+					t.Fatal(err)
+				}
+				return session.State
+			}
+			state := run()
+			if got := state.Answered(); got != 8 {
+				t.Errorf("answered %d questions, want 8", got)
+			}
+			var actions []conductor.Action
+			for _, turn := range state.Turns {
+				if !slices.Contains(actions, turn.Action) {
+					actions = append(actions, turn.Action)
+				}
+				if turn.Action != conductor.Summarize && turn.Answer == "" {
+					t.Errorf("question %q was left unanswered", turn.Question)
+				}
+			}
+			if !reflect.DeepEqual(actions, tt.want) {
+				t.Errorf("actions used = %v, want %v", actions, tt.want)
+			}
+
+			// The same seed has to give the same dialogue for regression tests.
+			again := run()
+			for i := range state.Turns {
+				a, b := state.Turns[i], again.Turns[i]
+				if a.Action != b.Action || a.Question != b.Question || a.Answer != b.Answer {
+					t.Fatalf("turn %d differs between runs with the same seed:\n%+v\n%+v", i, a, b)
+				}
+			}
+		})
+	}
+}
+
+func TestRunMaxAnswers(t *testing.T) {
+	session := newSession(conductor.ServerOnly{})
+	if err := Run(context.Background(), session, New(traits, IdentityBank, 1), 5); err != nil {
+		t.Fatal(err)
+	}
+	if got := session.State.Answered(); got != 5 {
+		t.Errorf("answered %d questions, want 5", got)
+	}
+}
+
+func TestAnswerTraits(t *testing.T) {
+	ctx := context.Background()
+
+	unsure := New(Traits{}, IdentityBank, 1)
+	if a, _ := unsure.Answer(ctx, conductor.State{}, "Q?"); !slices.Contains(IdentityBank.Unsure, a) {
+		t.Errorf("user without knowledge answered %q, want an unsure answer", a)
+	}
+
+	consistent := New(Traits{Knowledge: 1, Consistency: 1}, IdentityBank, 1)
+	first, _ := consistent.Answer(ctx, conductor.State{}, "Q1?")
+	second, _ := consistent.Answer(ctx, conductor.State{}, "Q2?")
+	if !slices.Contains(IdentityBank.Positions, first) {
+		t.Errorf("first answer %q is not a position", first)
+	}
+	if second != "As I said before: "+first && second != "I keep coming back to the same idea. "+first {
+		t.Errorf("consistent user answered %q, want the first position reaffirmed", second)
+	}
+
+	if _, err := New(Traits{Knowledge: 1}, Bank{}, 1).Answer(ctx, conductor.State{}, "Q?"); err == nil {
+		t.Error("empty bank gave an answer, want an error")
+	}
+}
+
+func TestTraitsValidate(t *testing.T) {
+	if err := traits.Validate(); err != nil {
+		t.Errorf("valid traits: %v", err)
+	}
+	if err := (Traits{Verbosity: 1.5}).Validate(); err == nil {
+		t.Error("verbosity 1.5 was accepted")
+	}
+	if err := (Traits{Contrarianism: -0.1}).Validate(); err == nil {
+		t.Error("contrarianism -0.1 was accepted")
+	}
+}
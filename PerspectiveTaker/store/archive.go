@@ -0,0 +1,105 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	ArchiveFormat  = "perspective-taker-store"
+	ArchiveVersion = 1
+)
+
+// Archive is a portable copy of the whole local store, used to move it to
+// another machine. Config holds the exported config file, if any.
+type Archive struct {
+	Format     string          `json:"format"`
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Sessions   []Session       `json:"sessions"`
+	Config     json.RawMessage `json:"config,omitempty"`
+}
+
+func (s *Store) Export() (Archive, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return Archive{}, err
+	}
+	defer unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return Archive{}, err
+	}
+	if f.Sessions == nil {
+		f.Sessions = []Session{}
+	}
+	return Archive{
+		Format:     ArchiveFormat,
+		Version:    ArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Sessions:   f.Sessions,
+	}, nil
+}
+
+// ReadArchive decodes an archive and rejects files that are not archives or
+// were written by a newer version of the CLI.
+func ReadArchive(r io.Reader) (Archive, error) {
+	var a Archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return a, fmt.Errorf("reading archive: %w", err)
+	}
+	if a.Format != ArchiveFormat {
+		return a, fmt.Errorf("not a %s archive", ArchiveFormat)
+	}
+	if a.Version < 1 || a.Version > ArchiveVersion {
+		return a, fmt.Errorf("archive version %d is not supported (this CLI reads up to version %d)", a.Version, ArchiveVersion)
+	}
+	return a, nil
+}
+
+type ImportResult struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Import merges the archived sessions into the store. A session that exists
+// on both sides is replaced only if the archived copy was updated later.
+func (s *Store) Import(a Archive) (ImportResult, error) {
+	var res ImportResult
+	unlock, err := s.lock()
+	if err != nil {
+		return res, err
+	}
+	defer unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return res, err
+	}
+	index := make(map[string]int, len(f.Sessions))
+	for i, sess := range f.Sessions {
+		index[sess.ID] = i
+	}
+	for _, sess := range a.Sessions {
+		i, ok := index[sess.ID]
+		switch {
+		case !ok:
+			index[sess.ID] = len(f.Sessions)
+			f.Sessions = append(f.Sessions, sess)
+			res.Added++
+		case sess.UpdatedAt.After(f.Sessions[i].UpdatedAt):
+			f.Sessions[i] = sess
+			res.Updated++
+		default:
+			res.Unchanged++
+		}
+	}
+	if res.Added+res.Updated == 0 {
+		return res, nil
+	}
+	return res, s.write(f)
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportMerge(t *testing.T) {
+	s := Open(t.TempDir())
+	old := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, id := range []string{"local", "stale", "fresh"} {
+		if err := s.Save(Session{ID: id, Status: StatusOpen, Turns: []Turn{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saved, err := s.Get("stale")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Import(Archive{Sessions: []Session{
+		{ID: "new", Status: StatusOpen, UpdatedAt: old},
+		{ID: "stale", Status: StatusEnded, UpdatedAt: old},
+		{ID: "fresh", Status: StatusEnded, UpdatedAt: saved.UpdatedAt.Add(time.Hour)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (ImportResult{Added: 1, Updated: 1, Unchanged: 1}) {
+		t.Errorf("result = %+v, want 1 added, 1 updated, 1 unchanged", result)
+	}
+
+	want := map[string]string{"local": StatusOpen, "new": StatusOpen, "stale": StatusOpen, "fresh": StatusEnded}
+	sessions, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != len(want) {
+		t.Fatalf("got %d sessions, want %d", len(sessions), len(want))
+	}
+	for _, sess := range sessions {
+		if sess.Status != want[sess.ID] {
+			t.Errorf("session %s has status %q, want %q", sess.ID, sess.Status, want[sess.ID])
+		}
+	}
+}
+
+func TestImportUnchangedLeavesStore(t *testing.T) {
+	s := Open(t.TempDir())
+	a, err := s.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Sessions) != 0 || a.Format != ArchiveFormat || a.Version != ArchiveVersion {
+		t.Errorf("export of an empty store = %+v", a)
+	}
+	if _, err := s.Import(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.List(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadArchive(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{`{"format":"perspective-taker-store","version":1,"sessions":[{"id":"a1"}]}`, ""},
+		{`{"format":"perspective-taker-store","version":2,"sessions":[]}`, "version 2 is not supported"},
+		{`{"format":"perspective-taker-store","version":0,"sessions":[]}`, "version 0 is not supported"},
+		{`{"format":"something-else","version":1}`, "not a perspective-taker-store archive"},
+		{`{"sessions":`, "reading archive"},
+	}
+	for _, tt := range tests {
+		a, err := ReadArchive(strings.NewReader(tt.input))
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.input, err)
+		case tt.wantErr == "" && (len(a.Sessions) != 1 || a.Sessions[0].ID != "a1"):
+			t.Errorf("%s: got sessions %+v", tt.input, a.Sessions)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: got error %v, want %q", tt.input, err, tt.wantErr)
+		}
+	}
+}
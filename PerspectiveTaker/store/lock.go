@@ -0,0 +1,30 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lock takes an exclusive lock on the store, blocking until any other
+// process using it (another CLI or the gateway) lets go. Every operation
+// holds it from read to write so concurrent saves cannot drop each other's
+// changes. The lock is on a separate file because the store itself is
+// replaced on every write.
+func (s *Store) lock() (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking %s: %w", s.path, err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
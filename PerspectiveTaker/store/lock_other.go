@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package store
+
+import "os"
+
+// Platforms without file locks fall back to unlocked access.
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Version is the current layout of the sessions file.
+const Version = 1
+
+// migrations[i] upgrades a sessions file from version i to i+1. Migrations
+// work on the decoded JSON so they do not depend on the current Go types.
+var migrations = []func(doc map[string]any) error{
+	migrateV0,
+}
+
+// migrateV0 upgrades files written before the layout was versioned, which
+// did not always record a session's status or turns.
+func migrateV0(doc map[string]any) error {
+	sessions, ok := doc["sessions"].([]any)
+	if !ok && doc["sessions"] != nil {
+		return fmt.Errorf("sessions is a %T, not a list", doc["sessions"])
+	}
+	for i, v := range sessions {
+		sess, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("session %d is not an object", i)
+		}
+		if _, ok := sess["status"]; !ok {
+			sess["status"] = StatusOpen
+		}
+		if sess["turns"] == nil {
+			sess["turns"] = []any{}
+		}
+	}
+	if sessions == nil {
+		doc["sessions"] = []any{}
+	}
+	return nil
+}
+
+// migrate upgrades data to the current version and saves it. The original
+// file is copied next to the store first. The upgrade happens in memory and
+// is only written, atomically, once every step has succeeded and the result
+// decodes, so a failed migration leaves the original file untouched.
+func (s *Store) migrate(data []byte, from int) (file, error) {
+	var f file
+	backup := fmt.Sprintf("%s.v%d.bak", s.path, from)
+	if err := os.WriteFile(backup, data, 0o600); err != nil {
+		return f, fmt.Errorf("backing up %s before migration: %w", s.path, err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return f, fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	if doc == nil {
+		return f, fmt.Errorf("migrating %s: not a sessions file (unchanged, backup at %s)", s.path, backup)
+	}
+	for v := from; v < Version; v++ {
+		if err := migrations[v](doc); err != nil {
+			return f, fmt.Errorf("migrating %s from version %d: %w (unchanged, backup at %s)", s.path, v, err, backup)
+		}
+		doc["version"] = v + 1
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err == nil {
+		err = json.Unmarshal(migrated, &f)
+	}
+	if err == nil {
+		err = s.write(f)
+	}
+	if err != nil {
+		return file{}, fmt.Errorf("migrating %s: %w (unchanged, backup at %s)", s.path, err, backup)
+	}
+	return f, nil
+}
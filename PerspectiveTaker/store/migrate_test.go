@@ -0,0 +1,98 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeStore(t *testing.T, content string) *Store {
+	t.Helper()
+	dir := t.TempDir()
+	s := Open(dir)
+	if err := os.WriteFile(s.Path(), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+const v0 = `{"sessions":[{"id":"a1","conductor":"server","turns":[{"source":"server","question":"Q?","answer":"A"}]},{"id":"b2","conductor":"hybrid"}]}`
+
+func TestMigrateV0(t *testing.T) {
+	s := writeStore(t, v0)
+
+	sessions, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+	for _, sess := range sessions {
+		if sess.Status != StatusOpen {
+			t.Errorf("session %s has status %q, want %q", sess.ID, sess.Status, StatusOpen)
+		}
+		if sess.Turns == nil {
+			t.Errorf("session %s has nil turns", sess.ID)
+		}
+	}
+
+	data, err := os.ReadFile(s.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header struct{ Version int }
+	if err := json.Unmarshal(data, &header); err != nil || header.Version != Version {
+		t.Errorf("migrated file has version %d (%v), want %d", header.Version, err, Version)
+	}
+
+	backup, err := os.ReadFile(s.Path() + ".v0.bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != v0 {
+		t.Errorf("backup = %s, want the original file", backup)
+	}
+}
+
+func TestMigrateFailureLeavesFile(t *testing.T) {
+	for _, tt := range []struct {
+		content string
+		backup  bool
+	}{
+		{`{"sessions":"oops"}`, true},
+		{`{"sessions":[1]}`, true},
+		{`{"sessions":[{"id":"a1","turns":"none"}]}`, true},
+		{`null`, true},
+		{`{"version":-1,"sessions":[]}`, false},
+	} {
+		s := writeStore(t, tt.content)
+		if _, err := s.List(); err == nil {
+			t.Errorf("%s: migration succeeded, want an error", tt.content)
+		} else if !strings.Contains(err.Error(), "unchanged") {
+			t.Errorf("%s: error %q does not say the file is unchanged", tt.content, err)
+		}
+		data, err := os.ReadFile(s.Path())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.content {
+			t.Errorf("%s: file was rewritten to %s", tt.content, data)
+		}
+		if _, err := os.Stat(s.Path() + ".v0.bak"); tt.backup && err != nil {
+			t.Errorf("%s: no backup: %v", tt.content, err)
+		}
+	}
+}
+
+func TestNewerVersionRefused(t *testing.T) {
+	s := writeStore(t, `{"version":99,"sessions":[]}`)
+	if _, err := s.List(); err == nil || !strings.Contains(err.Error(), "newer version") {
+		t.Errorf("got %v, want a newer-version error", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(s.Path()), "sessions.json.v99.bak")); err == nil {
+		t.Error("a newer file was backed up as if it were migrated")
+	}
+}
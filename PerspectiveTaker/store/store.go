@@ -0,0 +1,199 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	StatusOpen  = "open"
+	StatusEnded = "ended"
+)
+
+type Turn struct {
+	Source      string    `json:"source"`
+	Question    string    `json:"question"`
+	Answer      string    `json:"answer,omitempty"`
+	Time        time.Time `json:"time"`
+	Perspective string    `json:"perspective,omitempty"`
+}
+
+// Session is a dialogue as saved on disk. Conductor and MaxTurns are kept so
+// a resumed session continues under the same questioning policy.
+type Session struct {
+	ID         string    `json:"id"`
+	Status     string    `json:"status"`
+	Conductor  string    `json:"conductor"`
+	MaxTurns   int       `json:"max_turns,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Turns      []Turn    `json:"turns"`
+	Reflection string    `json:"reflection,omitempty"`
+	// Perspective is the one the user had taken when the session was last
+	// saved, so a resumed session continues from it.
+	Perspective string `json:"perspective,omitempty"`
+}
+
+func NewSession(conductor string, maxTurns int) Session {
+	now := time.Now().UTC()
+	return Session{
+		ID:        newID(),
+		Status:    StatusOpen,
+		Conductor: conductor,
+		MaxTurns:  maxTurns,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Turns:     []Turn{},
+	}
+}
+
+func newID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+var ErrNotFound = errors.New("session not found")
+
+// Store keeps sessions in a single JSON file.
+type Store struct {
+	path string
+}
+
+func Open(dir string) *Store {
+	return &Store{path: filepath.Join(dir, "sessions.json")}
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+type file struct {
+	Version  int       `json:"version"`
+	Sessions []Session `json:"sessions"`
+}
+
+func (s *Store) read() (file, error) {
+	var f file
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return f, fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	switch {
+	case header.Version > Version:
+		return f, fmt.Errorf("%s was written by a newer version of perspective-taker (store version %d, this one supports %d)", s.path, header.Version, Version)
+	case header.Version < 0 || header.Version < Version && header.Version >= len(migrations):
+		return f, fmt.Errorf("%s has unknown store version %d and cannot be migrated (unchanged)", s.path, header.Version)
+	case header.Version < Version:
+		return s.migrate(data, header.Version)
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	return f, nil
+}
+
+// write replaces the file atomically so a crash mid-write cannot leave a
+// truncated store behind.
+func (s *Store) write(f file) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	f.Version = Version
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".sessions-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// List returns all sessions, most recently updated first.
+func (s *Store) List() ([]Session, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(f.Sessions, func(i, j int) bool {
+		return f.Sessions[i].UpdatedAt.After(f.Sessions[j].UpdatedAt)
+	})
+	return f.Sessions, nil
+}
+
+func (s *Store) Get(id string) (Session, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return Session{}, err
+	}
+	defer unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return Session{}, err
+	}
+	for _, sess := range f.Sessions {
+		if sess.ID == id {
+			return sess, nil
+		}
+	}
+	return Session{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// Save inserts the session or replaces the stored session with the same ID.
+func (s *Store) Save(sess Session) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+	sess.UpdatedAt = time.Now().UTC()
+	for i := range f.Sessions {
+		if f.Sessions[i].ID == sess.ID {
+			f.Sessions[i] = sess
+			return s.write(f)
+		}
+	}
+	f.Sessions = append(f.Sessions, sess)
+	return s.write(f)
+}
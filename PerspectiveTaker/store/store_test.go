@@ -0,0 +1,135 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSaveGetList(t *testing.T) {
+	s := Open(t.TempDir())
+	if sessions, err := s.List(); err != nil || len(sessions) != 0 {
+		t.Fatalf("new store lists %v, %v; want nothing", sessions, err)
+	}
+
+	first := NewSession("server", 0)
+	second := NewSession("hybrid", 5)
+	for _, sess := range []Session{first, second} {
+		if err := s.Save(sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first.Turns = append(first.Turns, Turn{Source: "server", Question: "Q?", Answer: "A", Time: time.Now().UTC()})
+	if err := s.Save(first); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Turns) != 1 || got.Turns[0].Answer != "A" || !got.UpdatedAt.After(first.CreatedAt) {
+		t.Errorf("Get returned %+v", got)
+	}
+	sessions, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != first.ID || sessions[1].ID != second.ID {
+		t.Errorf("List returned %v, want the updated session first", sessions)
+	}
+	if _, err := s.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+const saves = 20
+
+// saveMany saves n new sessions through its own Store, as a separate CLI
+// process would.
+func saveMany(dir, prefix string, n int) error {
+	s := Open(dir)
+	for i := 0; i < n; i++ {
+		if err := s.Save(Session{ID: fmt.Sprintf("%s-%d", prefix, i), Status: StatusOpen, Turns: []Turn{}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSaved verifies that the store file is valid JSON holding want sessions.
+func checkSaved(t *testing.T, dir string, want int) {
+	t.Helper()
+	data, err := os.ReadFile(Open(dir).Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatalf("store is not valid JSON: %v", err)
+	}
+	ids := map[string]bool{}
+	for _, sess := range f.Sessions {
+		ids[sess.ID] = true
+	}
+	if len(f.Sessions) != want || len(ids) != want {
+		t.Errorf("store has %d sessions (%d distinct), want %d", len(f.Sessions), len(ids), want)
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	dir := t.TempDir()
+	const writers = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			errs <- saveMany(dir, fmt.Sprint("g", w), saves)
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkSaved(t, dir, writers*saves)
+}
+
+// TestCrossProcessSaves runs this test binary as several helper processes
+// that save to the same store at once.
+func TestCrossProcessSaves(t *testing.T) {
+	if dir := os.Getenv("STORE_HELPER_DIR"); dir != "" {
+		if err := saveMany(dir, os.Getenv("STORE_HELPER_PREFIX"), saves); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	dir := t.TempDir()
+	const writers = 3
+	cmds := make([]*exec.Cmd, writers)
+	for w := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCrossProcessSaves$")
+		cmd.Env = append(os.Environ(), "STORE_HELPER_DIR="+dir, fmt.Sprint("STORE_HELPER_PREFIX=p", w))
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds[w] = cmd
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("helper process failed: %v", err)
+		}
+	}
+	checkSaved(t, dir, writers*saves)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"perspective_taker/config"
+	"perspective_taker/store"
+
+	"github.com/spf13/cobra"
+)
+
+func storeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "store",
+		Short: "Move the local store between machines",
+		Args:  usageArgs(cobra.NoArgs),
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "export [file]",
+		Short: "Write all saved sessions and the config file to a portable archive",
+		Long: `Write all saved sessions and the config file to a portable archive, or to
+stdout when no file (or -) is given. The API key is left out of the archive,
+and credentials in URL options are masked.`,
+		Args: usageArgs(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := "-"
+			if len(args) == 1 {
+				path = args[0]
+			}
+			return exportStore(path)
+		},
+	}, &cobra.Command{
+		Use:   "import <file>",
+		Short: "Merge an archive written by 'store export' into the local store",
+		Long: `Merge an archive written by 'store export' into the local store. Sessions
+missing here are added and sessions updated more recently in the archive
+replace the local copy. The archived config is only used if there is no
+config file yet.`,
+		Args: usageArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return importStore(args[0])
+		},
+	})
+	return cmd
+}
+
+// exportSummary is the --json result of exporting to a file. Exporting to
+// stdout prints the archive itself.
+type exportSummary struct {
+	Path     string `json:"path"`
+	Sessions int    `json:"sessions"`
+	Config   bool   `json:"config"`
+}
+
+func exportStore(path string) error {
+	sessions, err := openStore()
+	if err != nil {
+		return err
+	}
+	archive, err := sessions.Export()
+	if err != nil {
+		return err
+	}
+	archive.Config, err = exportConfig()
+	if err != nil {
+		return fmt.Errorf("exporting config: %w", err)
+	}
+
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(exportSummary{Path: path, Sessions: len(archive.Sessions), Config: archive.Config != nil})
+	}
+	fmt.Fprintf(ui(), "Exported %d sessions to %s\n", len(archive.Sessions), path)
+	return nil
+}
+
+// exportConfig returns the config file in use without its secrets, or nil
+// if there is no config file.
+func exportConfig() (json.RawMessage, error) {
+	if configPath == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config.Portable(data)
+}
+
+type importSummary struct {
+	store.ImportResult
+	ConfigRestored bool `json:"config_restored"`
+}
+
+func importStore(path string) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return usageError{err}
+		}
+		defer f.Close()
+		r = f
+	}
+	archive, err := store.ReadArchive(r)
+	if err != nil {
+		return usageError{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	sessions, err := openStore()
+	if err != nil {
+		return err
+	}
+	result, err := sessions.Import(archive)
+	if err != nil {
+		return err
+	}
+	summary := importSummary{ImportResult: result}
+	summary.ConfigRestored, err = importConfig(archive.Config)
+	if err != nil {
+		return fmt.Errorf("importing config: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(summary)
+	}
+	fmt.Printf("Imported %d new and %d updated sessions (%d already up to date).\n",
+		result.Added, result.Updated, result.Unchanged)
+	if summary.ConfigRestored {
+		fmt.Println("Restored the config file; set your API key again with --api-key or " + config.EnvPrefix + "API_KEY,")
+		fmt.Println("and replace any credentials masked with **** in its URLs.")
+	}
+	return nil
+}
+
+// importConfig writes the archived config to the config file in use, unless
+// that file already exists.
+func importConfig(data json.RawMessage) (bool, error) {
+	path := configPath
+	if len(data) == 0 || path == "" {
+		return false, nil
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return false, err
+	}
+	b.WriteByte('\n')
+	return true, os.WriteFile(path, b.Bytes(), 0o600)
+}
@@ -0,0 +1,157 @@
+// Package telemetry aggregates anonymous usage counts locally for users who
+// opt in. Only command names and error categories are counted; no arguments,
+// answers or other content are recorded.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// Disabled reports whether the user has turned telemetry off for every tool
+// through the DO_NOT_TRACK convention. It overrides the config.
+func Disabled() bool {
+	v := os.Getenv("DO_NOT_TRACK")
+	return v != "" && v != "0" && v != "false"
+}
+
+// Report is exactly what is sent.
+type Report struct {
+	Version  string         `json:"version"`
+	OS       string         `json:"os"`
+	Arch     string         `json:"arch"`
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Commands map[string]int `json:"commands"`
+	Errors   map[string]int `json:"errors"`
+}
+
+type state struct {
+	Pending  Report    `json:"pending"`
+	LastSent time.Time `json:"last_sent,omitempty"`
+}
+
+// Recorder keeps the counts in a file until they are sent.
+type Recorder struct {
+	path    string
+	version string
+}
+
+func Open(dir, version string) *Recorder {
+	return &Recorder{path: filepath.Join(dir, "telemetry.json"), version: version}
+}
+
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+func (r *Recorder) read() (state, error) {
+	var s state
+	data, err := os.ReadFile(r.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return s, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return s, fmt.Errorf("parsing %s: %w", r.path, err)
+		}
+	}
+	if s.Pending.Commands == nil {
+		s.Pending.Commands = map[string]int{}
+	}
+	if s.Pending.Errors == nil {
+		s.Pending.Errors = map[string]int{}
+	}
+	s.Pending.Version, s.Pending.OS, s.Pending.Arch = r.version, runtime.GOOS, runtime.GOARCH
+	return s, nil
+}
+
+func (r *Recorder) write(s state) error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o600)
+}
+
+// Record counts one run of command. errorKind is empty for a successful
+// run, otherwise a fixed category such as "usage".
+func (r *Recorder) Record(command, errorKind string) error {
+	s, err := r.read()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if s.Pending.From.IsZero() {
+		s.Pending.From = now
+	}
+	s.Pending.To = now
+	s.Pending.Commands[command]++
+	if errorKind != "" {
+		s.Pending.Errors[errorKind]++
+	}
+	return r.write(s)
+}
+
+// Pending returns the report that the next Send would upload, and when the
+// last one was sent.
+func (r *Recorder) Pending() (Report, time.Time, error) {
+	s, err := r.read()
+	return s.Pending, s.LastSent, err
+}
+
+// Due reports whether a report is waiting and the last one was sent at
+// least interval ago.
+func (r *Recorder) Due(interval time.Duration) bool {
+	s, err := r.read()
+	return err == nil && len(s.Pending.Commands) > 0 && time.Since(s.LastSent) >= interval
+}
+
+// Send uploads the pending report to url and starts a new one.
+func (r *Recorder) Send(ctx context.Context, url string) error {
+	s, err := r.read()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(s.Pending)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sending telemetry: %s", resp.Status)
+	}
+	return r.write(state{LastSent: time.Now().UTC()})
+}
+
+// Clear deletes everything collected so far.
+func (r *Recorder) Clear() error {
+	err := os.Remove(r.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
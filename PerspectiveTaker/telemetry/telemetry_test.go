@@ -0,0 +1,116 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDisabled(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "0": false, "false": false, "1": true, "true": true, "yes": true} {
+		t.Setenv("DO_NOT_TRACK", value)
+		if got := Disabled(); got != want {
+			t.Errorf("DO_NOT_TRACK=%q: Disabled() = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	r := Open(t.TempDir(), "1.2.3")
+	if r.Due(0) {
+		t.Error("an empty recorder is due")
+	}
+	for _, run := range [][2]string{{"dialogue", ""}, {"dialogue", "usage"}, {"search", "error"}} {
+		if err := r.Record(run[0], run[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report, lastSent, err := r.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Version != "1.2.3" || report.Commands["dialogue"] != 2 || report.Commands["search"] != 1 ||
+		report.Errors["usage"] != 1 || report.Errors["error"] != 1 || report.From.IsZero() || report.To.Before(report.From) {
+		t.Errorf("pending report = %+v", report)
+	}
+	if !lastSent.IsZero() || !r.Due(time.Hour) {
+		t.Errorf("never-sent counts should be due (last sent %v)", lastSent)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with content type %q", req.Method, req.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	r := Open(t.TempDir(), "1.2.3")
+	if err := r.Record("list", ""); err != nil {
+		t.Fatal(err)
+	}
+	want, _, _ := r.Pending()
+	if err := r.Send(context.Background(), srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if got.Commands["list"] != 1 || !got.From.Equal(want.From) {
+		t.Errorf("server received %+v, want %+v", got, want)
+	}
+
+	report, lastSent, err := r.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Commands) != 0 || time.Since(lastSent) > time.Minute {
+		t.Errorf("after sending: pending %+v, last sent %v", report, lastSent)
+	}
+	if err := r.Record("list", ""); err != nil {
+		t.Fatal(err)
+	}
+	if r.Due(time.Hour) {
+		t.Error("due again within the interval")
+	}
+}
+
+func TestSendFailureKeepsCounts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	r := Open(t.TempDir(), "1.2.3")
+	if err := r.Record("list", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Send(context.Background(), srv.URL); err == nil {
+		t.Error("Send succeeded against a failing server")
+	}
+	if report, _, _ := r.Pending(); report.Commands["list"] != 1 {
+		t.Errorf("counts lost after a failed send: %+v", report)
+	}
+}
+
+func TestClear(t *testing.T) {
+	r := Open(t.TempDir(), "1.2.3")
+	if err := r.Clear(); err != nil {
+		t.Errorf("clearing nothing: %v", err)
+	}
+	if err := r.Record("list", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(r.Path()); !os.IsNotExist(err) {
+		t.Errorf("telemetry file still there after Clear: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"perspective_taker/config"
+	"perspective_taker/telemetry"
+
+	"github.com/spf13/cobra"
+)
+
+const telemetryInterval = 24 * time.Hour
+
+func openTelemetry() (*telemetry.Recorder, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return telemetry.Open(dir, version), nil
+}
+
+func telemetryEnabled() bool {
+	return cfg.Telemetry && !telemetry.Disabled()
+}
+
+// recordUsage counts the command that just ran, if the user opted in, and
+// sends the counts at most once a day. Failures are only logged; telemetry
+// must never get in the way of the command.
+func recordUsage(cmd *cobra.Command, err error) {
+	if cmd == nil || !telemetryEnabled() {
+		return
+	}
+	rec, recErr := openTelemetry()
+	if recErr != nil {
+		logger.Debug("telemetry unavailable", "err", recErr)
+		return
+	}
+
+	name := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+	if name == "" {
+		name = "interactive"
+	}
+	kind := ""
+	switch exitCode(err) {
+	case exitUsage:
+		kind = "usage"
+	case exitError:
+		kind = "error"
+	}
+	if err := rec.Record(name, kind); err != nil {
+		logger.Debug("recording telemetry failed", "err", err)
+		return
+	}
+
+	if cfg.TelemetryURL != "" && rec.Due(telemetryInterval) {
+		if err := rec.Send(context.Background(), cfg.TelemetryURL); err != nil {
+			logger.Debug("sending telemetry failed", "err", err)
+		}
+	}
+}
+
+func telemetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Inspect the anonymous usage counts collected when telemetry is on",
+		Args:  usageArgs(cobra.NoArgs),
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "Show whether telemetry is on and exactly what would be sent",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return showTelemetry()
+		},
+	}, &cobra.Command{
+		Use:   "clear",
+		Short: "Delete the usage counts collected so far",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rec, err := openTelemetry()
+			if err != nil {
+				return err
+			}
+			if err := rec.Clear(); err != nil {
+				return err
+			}
+			if jsonOutput {
+				return printJSON(telemetryCleared{Cleared: true, Path: rec.Path()})
+			}
+			fmt.Fprintln(ui(), "Deleted the collected usage counts.")
+			return nil
+		},
+	})
+	return cmd
+}
+
+type telemetryStatus struct {
+	Enabled     bool             `json:"enabled"`
+	DoNotTrack  bool             `json:"do_not_track"`
+	URL         string           `json:"url,omitempty"`
+	LastSent    *time.Time       `json:"last_sent,omitempty"`
+	NextPayload telemetry.Report `json:"next_payload"`
+}
+
+type telemetryCleared struct {
+	Cleared bool   `json:"cleared"`
+	Path    string `json:"path"`
+}
+
+func showTelemetry() error {
+	rec, err := openTelemetry()
+	if err != nil {
+		return err
+	}
+	pending, lastSent, err := rec.Pending()
+	if err != nil {
+		return err
+	}
+	status := telemetryStatus{
+		Enabled:     telemetryEnabled(),
+		DoNotTrack:  telemetry.Disabled(),
+		URL:         cfg.TelemetryURL,
+		NextPayload: pending,
+	}
+	if !lastSent.IsZero() {
+		status.LastSent = &lastSent
+	}
+	if jsonOutput {
+		return printJSON(status)
+	}
+
+	switch {
+	case status.DoNotTrack:
+		fmt.Println("Telemetry is off because DO_NOT_TRACK is set.")
+	case !status.Enabled:
+		fmt.Println("Telemetry is off. Nothing is collected or sent unless you set telemetry to true.")
+	case status.URL == "":
+		fmt.Println("Telemetry is on, but no telemetry_url is set, so counts stay on this machine.")
+	default:
+		fmt.Printf("Telemetry is on. Counts are sent to %s at most once a day.\n", status.URL)
+	}
+	if status.LastSent != nil {
+		fmt.Println("Last sent:", status.LastSent.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("Collected in %s. The next report would be exactly:\n", rec.Path())
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
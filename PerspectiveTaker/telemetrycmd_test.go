@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRecordUsageOptOut(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		doNotTrack string
+		want       bool
+	}{
+		{"off by default", false, "", false},
+		{"opted in", true, "", true},
+		{"DO_NOT_TRACK overrides the config", true, "1", false},
+	}
+	for _, tt := range tests {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("APPDATA", home)
+		t.Setenv("DO_NOT_TRACK", tt.doNotTrack)
+		cfg.Telemetry, cfg.TelemetryURL = tt.enabled, ""
+
+		recordUsage(&cobra.Command{Use: "list"}, nil)
+
+		rec, err := openTelemetry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = os.Stat(rec.Path())
+		if recorded := err == nil; recorded != tt.want {
+			t.Errorf("%s: recorded = %v, want %v", tt.name, recorded, tt.want)
+		}
+	}
+	cfg.Telemetry = false
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package terminal
+
+import "os"
+
+// EnableANSI reports whether ANSI escape sequences written to f will be
+// rendered. Unix terminals render them without setup.
+func EnableANSI(f *os.File) bool {
+	return IsTerminal(f)
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package terminal
+
+import (
+	"os"
+	"syscall"
+)
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+const enableVirtualTerminalProcessing = 0x4
+
+// EnableANSI switches the console behind f to processing ANSI escape
+// sequences and reports whether that worked. Consoles older than Windows 10
+// refuse, in which case callers should write plain text.
+func EnableANSI(f *os.File) bool {
+	var mode uint32
+	h := syscall.Handle(f.Fd())
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		return false
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	r, _, _ := procSetConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing))
+	return r != 0
+}
@@ -0,0 +1,12 @@
+// Package terminal hides the differences between Unix terminals and the
+// Windows console.
+package terminal
+
+import "os"
+
+// IsTerminal reports whether f is an interactive terminal rather than a
+// file or pipe.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"perspective_taker/conductor"
+	"perspective_taker/store"
+	"perspective_taker/terminal"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const perspectivePrefix = "/perspective "
+
+// runDialogueTUI runs a dialogue in a full-screen terminal UI: a scrollable
+// transcript, a sidebar summarizing the session and an input box. Like the
+// line-oriented loop it saves after every answer, so quitting midway leaves
+// a session that can be resumed. Without a terminal it falls back to the
+// line-oriented loop.
+func runDialogueTUI(ctx context.Context, session *conductor.Session, record *store.Session) error {
+	if jsonOutput {
+		return usageError{errors.New("--tui cannot be combined with --json")}
+	}
+	if !terminal.IsTerminal(os.Stdin) || !terminal.IsTerminal(os.Stdout) {
+		logger.Warn("--tui needs an interactive terminal; using the line-oriented dialogue")
+		return runDialogue(ctx, session, record)
+	}
+
+	m := newTUIModel(ctx, session, record)
+	// Bubble Tea would print a panic and return normally. Restore the
+	// terminal and let the panic reach the crash reporter instead.
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithContext(ctx), tea.WithoutCatchPanics())
+	defer func() {
+		if r := recover(); r != nil {
+			p.ReleaseTerminal()
+			panic(r)
+		}
+	}()
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+	if m := final.(*tuiModel); m.err != nil {
+		return m.err
+	}
+	if record.Status == store.StatusOpen {
+		fmt.Printf("Session %s saved. Continue it later with: perspective-taker resume %s --tui\n", record.ID, record.ID)
+	}
+	return nil
+}
+
+type entryKind int
+
+const (
+	entryQuestion entryKind = iota
+	entryAnswer
+	entrySummary
+	entryNote
+)
+
+type entry struct {
+	kind entryKind
+	text string
+	// perspective is the one an answer was given from.
+	perspective string
+}
+
+type tuiModel struct {
+	ctx     context.Context
+	session *conductor.Session
+	record  *store.Session
+
+	entries []entry
+	// reflecting is set once the dialogue is over and the closing
+	// reflection question is open.
+	reflecting bool
+	err        error
+
+	transcript viewport.Model
+	input      textinput.Model
+	width      int
+	height     int
+}
+
+func newTUIModel(ctx context.Context, session *conductor.Session, record *store.Session) *tuiModel {
+	input := textinput.New()
+	input.Placeholder = "Your answer"
+	input.Prompt = "> "
+	input.Focus()
+
+	m := &tuiModel{ctx: ctx, session: session, record: record, input: input, transcript: viewport.New(0, 0)}
+	for _, t := range session.State.Turns {
+		m.addTurn(t)
+	}
+	if len(record.Turns) > 0 {
+		m.note(fmt.Sprintf("Resuming dialectic session %s where you left off.", record.ID))
+	}
+	return m
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	m.advance()
+	if m.err != nil || m.record.Status == store.StatusEnded && !m.reflecting {
+		return tea.Quit
+	}
+	return textinput.Blink
+}
+
+func (m *tuiModel) addTurn(t conductor.Turn) {
+	switch t.Action {
+	case conductor.Summarize:
+		if t.Question != "" {
+			m.entries = append(m.entries, entry{entrySummary, t.Question, ""})
+		}
+	case conductor.UserAsk:
+		m.entries = append(m.entries, entry{entryAnswer, askPrefix + t.Question, t.Perspective}, entry{entryQuestion, t.Answer, ""})
+	default:
+		m.entries = append(m.entries, entry{entryQuestion, t.Question, ""})
+		if t.Answer != "" {
+			m.entries = append(m.entries, entry{entryAnswer, t.Answer, t.Perspective})
+		}
+	}
+}
+
+// note adds a line of commentary to the transcript. Callers pass on the
+// notices from checkResponse, which are usually empty.
+func (m *tuiModel) note(text string) {
+	if text = strings.TrimSpace(text); text == "" {
+		return
+	}
+	m.entries = append(m.entries, entry{entryNote, text, ""})
+}
+
+// advance moves the dialogue on until a question needs answering.
+func (m *tuiModel) advance() {
+	if _, pending := m.session.Pending(); pending {
+		return
+	}
+	for {
+		t, err := m.session.Next(m.ctx)
+		if err != nil {
+			m.err = err
+			return
+		}
+		if t.Action == conductor.End {
+			m.note("That's the end of this dialogue.")
+			m.finish()
+			return
+		}
+		saveSession(m.record, m.session)
+		m.addTurn(t)
+		if t.Action != conductor.Summarize {
+			return
+		}
+	}
+}
+
+// finish ends the session and asks for the closing reflection, if there is
+// anything to reflect on.
+func (m *tuiModel) finish() {
+	m.record.Status = store.StatusEnded
+	saveSession(m.record, m.session)
+	if m.session.State.Answered() > 0 {
+		m.reflecting = true
+		m.input.Placeholder = "press enter to skip"
+		m.entries = append(m.entries, entry{entryQuestion, "Before you go: what surprised you about your own thinking in this dialogue?", ""})
+	}
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.layout()
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, tea.Quit
+		case tea.KeyCtrlP:
+			m.input.SetValue(perspectivePrefix)
+			m.input.CursorEnd()
+			return m, nil
+		case tea.KeyPgUp, tea.KeyPgDown:
+			var cmd tea.Cmd
+			m.transcript, cmd = m.transcript.Update(msg)
+			return m, cmd
+		case tea.KeyEnter:
+			if m.submit(m.input.Value()) {
+				return m, tea.Quit
+			}
+			m.input.Reset()
+			m.layout()
+			return m, nil
+		}
+
+	case tea.MouseMsg:
+		var cmd tea.Cmd
+		m.transcript, cmd = m.transcript.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// submit handles a line from the input box and reports whether the
+// program should exit.
+func (m *tuiModel) submit(line string) bool {
+	if m.reflecting {
+		var notices strings.Builder
+		text, ok := checkResponse(&notices, line)
+		m.note(notices.String())
+		if !ok {
+			return false
+		}
+		m.record.Reflection = text
+		saveSession(m.record, m.session)
+		return true
+	}
+
+	if name, ok := strings.CutPrefix(line, perspectivePrefix); ok {
+		m.session.Perspective = strings.TrimSpace(name)
+		saveSession(m.record, m.session)
+		if m.session.Perspective == "" {
+			m.note("Answering from your own perspective again.")
+		} else {
+			m.note("Perspective switched to " + m.session.Perspective + ".")
+		}
+		logger.Debug("perspective switched", "session", m.record.ID, "perspective", m.session.Perspective)
+		return false
+	}
+
+	var notices strings.Builder
+	text, ok := checkResponse(&notices, line)
+	m.note(notices.String())
+	if !ok || text == "" {
+		return false
+	}
+
+	switch {
+	case text == "end":
+		m.note("Ending dialogue...")
+		m.finish()
+		return !m.reflecting
+	case strings.HasPrefix(text, askPrefix):
+		t, err := m.session.Ask(m.ctx, strings.TrimSpace(strings.TrimPrefix(text, askPrefix)))
+		if err != nil {
+			m.note(err.Error())
+			return false
+		}
+		saveSession(m.record, m.session)
+		m.addTurn(t)
+		return false
+	}
+
+	if err := m.session.Answer(text); err != nil {
+		m.err = err
+		return true
+	}
+	saveSession(m.record, m.session)
+	m.entries = append(m.entries, entry{entryAnswer, text, m.session.Perspective})
+	m.advance()
+	if m.err != nil {
+		return true
+	}
+	return m.record.Status == store.StatusEnded && !m.reflecting
+}
+
+var (
+	questionStyle = lipgloss.NewStyle().Bold(true)
+	answerStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	summaryStyle  = lipgloss.NewStyle().Italic(true)
+	noteStyle     = lipgloss.NewStyle().Faint(true)
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+const sidebarWidth = 30
+
+// layout sizes the panes to the window and re-wraps the transcript.
+func (m *tuiModel) layout() {
+	if m.width == 0 {
+		return
+	}
+	frameW, frameH := paneStyle.GetFrameSize()
+	// The sidebar's width includes its padding but not its border.
+	m.transcript.Width = max(m.width-sidebarWidth-2-frameW, 10)
+	m.transcript.Height = max(m.height-2*frameH-2, 3)
+	m.input.Width = m.width - frameW - len(m.input.Prompt) - 1
+
+	var b strings.Builder
+	wrap := lipgloss.NewStyle().Width(m.transcript.Width)
+	for i, e := range m.entries {
+		if i > 0 && e.kind == entryQuestion {
+			b.WriteString("\n")
+		}
+		var text string
+		switch e.kind {
+		case entryQuestion:
+			text = questionStyle.Render(wrap.Render(e.text))
+		case entryAnswer:
+			who := "You"
+			if e.perspective != "" {
+				who += " (as " + e.perspective + ")"
+			}
+			text = answerStyle.Render(wrap.Render(who + ": " + e.text))
+		case entrySummary:
+			text = summaryStyle.Render(wrap.Render(e.text))
+		case entryNote:
+			text = noteStyle.Render(wrap.Render(e.text))
+		}
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+	m.transcript.SetContent(b.String())
+	m.transcript.GotoBottom()
+}
+
+func (m *tuiModel) View() string {
+	if m.width == 0 {
+		return "Starting dialogue..."
+	}
+	left := paneStyle.Render(m.transcript.View())
+	right := paneStyle.Copy().Width(sidebarWidth).Height(m.transcript.Height).Render(m.sidebar())
+	body := lipgloss.JoinHorizontal(lipgloss.Top, left, right)
+	input := paneStyle.Copy().Width(m.width - 2).Render(m.input.View())
+	help := helpStyle.Copy().MaxWidth(m.width).Render("enter send · /ask ask the agent · ctrl+p perspective · pgup/pgdn scroll · esc quit")
+	return lipgloss.JoinVertical(lipgloss.Left, body, input, help)
+}
+
+// sidebar summarizes the session: where it stands and the themes that come
+// up most in the user's answers.
+func (m *tuiModel) sidebar() string {
+	var b strings.Builder
+	perspective := m.session.Perspective
+	if perspective == "" {
+		perspective = "your own"
+	}
+	fmt.Fprintf(&b, "Session %s\n", m.record.ID)
+	fmt.Fprintf(&b, "Conductor: %s\n", m.record.Conductor)
+	fmt.Fprintf(&b, "Perspective: %s\n", perspective)
+	fmt.Fprintf(&b, "Answers: %d\n\n", m.session.State.Answered())
+	b.WriteString(questionStyle.Render("Themes in your answers"))
+	b.WriteString("\n")
+	themes := answerThemes(m.session.State, 8)
+	if len(themes) == 0 {
+		b.WriteString(noteStyle.Render("none yet"))
+	}
+	for _, t := range themes {
+		fmt.Fprintf(&b, "  %s\n", t)
+	}
+	return b.String()
+}
+
+// answerThemes returns the content words used in the most answers.
+func answerThemes(s conductor.State, n int) []string {
+	counts := map[string]int{}
+	for _, t := range s.Turns {
+		if (t.Action == conductor.AskServer || t.Action == conductor.AskLocal) && t.Answer != "" {
+			for w := range conductor.ContentWords(t.Answer) {
+				counts[w]++
+			}
+		}
+	}
+	words := make([]string, 0, len(counts))
+	for w := range counts {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	return words[:min(n, len(words))]
+}
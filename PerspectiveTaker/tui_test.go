@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"perspective_taker/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestTUI starts a two-question server dialogue in the TUI model, with
+// sessions saved under a temporary home.
+func newTestTUI(t *testing.T) *tuiModel {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("APPDATA", home)
+
+	record := store.NewSession("server", 2)
+	session, err := newSession(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newTUIModel(context.Background(), session, &record)
+	if cmd := m.Init(); m.err != nil || isQuit(cmd) {
+		t.Fatalf("Init quit early: %v", m.err)
+	}
+	return m
+}
+
+// typeLine types line into the input box a key at a time and presses enter,
+// returning the command from the enter key. Sending the whole line as one
+// message would not work: the runes of "end" match the End key binding.
+func typeLine(m *tuiModel, line string) tea.Cmd {
+	for _, r := range line {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return cmd
+}
+
+func isQuit(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	_, ok := cmd().(tea.QuitMsg)
+	return ok
+}
+
+func checkEntries(t *testing.T, m *tuiModel, want []entry) {
+	t.Helper()
+	if len(m.entries) != len(want) {
+		t.Fatalf("transcript has %d entries %+v, want %d", len(m.entries), m.entries, len(want))
+	}
+	for i, e := range m.entries {
+		if e != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestTUIDialogue(t *testing.T) {
+	m := newTestTUI(t)
+	q1, q2 := "What are your initial thoughts on the concept of personal identity?", "How does this relate to the continuity or change over time?"
+
+	if cmd := typeLine(m, "Memory makes me who I am"); isQuit(cmd) {
+		t.Fatal("quit after the first answer")
+	}
+	if m.input.Value() != "" {
+		t.Errorf("input not cleared: %q", m.input.Value())
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	if m.input.Value() != perspectivePrefix {
+		t.Errorf("Ctrl+P left %q in the input, want %q", m.input.Value(), perspectivePrefix)
+	}
+	typeLine(m, "Stoic")
+	typeLine(m, "Character matters more")
+
+	checkEntries(t, m, []entry{
+		{entryQuestion, q1, ""},
+		{entryAnswer, "Memory makes me who I am", ""},
+		{entryQuestion, q2, ""},
+		{entryNote, "Perspective switched to Stoic.", ""},
+		{entryAnswer, "Character matters more", "Stoic"},
+		{entryNote, "That's the end of this dialogue.", ""},
+		{entryQuestion, "Before you go: what surprised you about your own thinking in this dialogue?", ""},
+	})
+	if !m.reflecting || m.record.Status != store.StatusEnded {
+		t.Fatalf("reflecting = %v, status = %q after the last answer", m.reflecting, m.record.Status)
+	}
+
+	if cmd := typeLine(m, "How much I rely on memory"); !isQuit(cmd) {
+		t.Error("the reflection did not end the program")
+	}
+	saved, err := mustStore(t).Get(m.record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != store.StatusEnded || saved.Reflection != "How much I rely on memory" || saved.Perspective != "Stoic" {
+		t.Errorf("saved session %+v", saved)
+	}
+	if len(saved.Turns) != 2 || saved.Turns[0].Perspective != "" || saved.Turns[1].Perspective != "Stoic" {
+		t.Errorf("saved turns %+v", saved.Turns)
+	}
+}
+
+func TestTUIEmptyAndEnd(t *testing.T) {
+	m := newTestTUI(t)
+	typeLine(m, "")
+	if len(m.entries) != 1 {
+		t.Errorf("an empty line changed the transcript: %+v", m.entries)
+	}
+
+	if cmd := typeLine(m, "end"); !isQuit(cmd) {
+		t.Error("ending before any answer did not quit")
+	}
+	if m.reflecting || m.record.Status != store.StatusEnded {
+		t.Errorf("reflecting = %v, status = %q; want no reflection and an ended session", m.reflecting, m.record.Status)
+	}
+}
+
+func TestTUIAsk(t *testing.T) {
+	m := newTestTUI(t)
+	typeLine(m, "Memory makes me who I am")
+	typeLine(m, "/ask what do I believe about memory?")
+
+	last := m.entries[len(m.entries)-2:]
+	if last[0] != (entry{entryAnswer, "/ask what do I believe about memory?", ""}) || last[1].kind != entryQuestion {
+		t.Errorf("ask left %+v at the end of the transcript", last)
+	}
+	if _, pending := m.session.Pending(); !pending {
+		t.Error("asking the agent closed the open question")
+	}
+}
+
+func mustStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
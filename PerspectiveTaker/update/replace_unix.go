@@ -0,0 +1,10 @@
+//go:build !windows
+
+package update
+
+import "os"
+
+// replace renames over the running binary, which Unix allows.
+func replace(src, dst string) error {
+	return os.Rename(src, dst)
+}
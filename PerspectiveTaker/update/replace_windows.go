@@ -0,0 +1,21 @@
+//go:build windows
+
+package update
+
+import "os"
+
+// replace moves the running binary aside first, because Windows will not
+// overwrite an executable that is in use. The old copy is left as .old and
+// removed by the next update.
+func replace(src, dst string) error {
+	old := dst + ".old"
+	os.Remove(old)
+	if err := os.Rename(dst, old); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		os.Rename(old, dst)
+		return err
+	}
+	return nil
+}
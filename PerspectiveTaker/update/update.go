@@ -0,0 +1,199 @@
+// Package update checks a release endpoint for new versions of the CLI and
+// replaces the running binary with a verified download.
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Release is a signed release manifest. Assets are keyed by "GOOS-GOARCH".
+type Release struct {
+	Version string           `json:"version"`
+	Notes   string           `json:"notes,omitempty"`
+	Assets  map[string]Asset `json:"assets"`
+}
+
+// Asset is one platform's binary. Its checksum is covered by the manifest
+// signature, so a matching download is signed too.
+type Asset struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// document is what the release endpoint serves: the base64 JSON manifest and
+// the base64 ed25519 signature of the manifest bytes by the release key.
+// Signing the whole manifest, version included, stops an endpoint from
+// passing off an older signed binary as a newer release.
+type document struct {
+	Manifest  string `json:"manifest"`
+	Signature string `json:"signature"`
+}
+
+func Platform() string {
+	return runtime.GOOS + "-" + runtime.GOARCH
+}
+
+var client = &http.Client{Timeout: 5 * time.Minute}
+
+// Check fetches the latest release from url and verifies its manifest
+// against publicKey.
+func Check(ctx context.Context, url string, publicKey ed25519.PublicKey) (Release, error) {
+	body, err := get(ctx, url, 1<<20)
+	if err != nil {
+		return Release{}, err
+	}
+	r, err := Parse(body, publicKey)
+	if err != nil {
+		return r, fmt.Errorf("release from %s: %w", url, err)
+	}
+	return r, nil
+}
+
+// Parse verifies a release document and returns its manifest.
+func Parse(body []byte, publicKey ed25519.PublicKey) (Release, error) {
+	var r Release
+	var doc document
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return r, fmt.Errorf("parsing: %w", err)
+	}
+	manifest, err := base64.StdEncoding.DecodeString(doc.Manifest)
+	if err != nil {
+		return r, fmt.Errorf("decoding manifest: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(doc.Signature)
+	if err != nil {
+		return r, fmt.Errorf("decoding signature: %w", err)
+	}
+	if !ed25519.Verify(publicKey, manifest, sig) {
+		return r, errors.New("manifest is not signed by the release key")
+	}
+	if err := json.Unmarshal(manifest, &r); err != nil {
+		return r, fmt.Errorf("parsing manifest: %w", err)
+	}
+	if r.Version == "" {
+		return r, errors.New("manifest has no version")
+	}
+	return r, nil
+}
+
+// Download fetches the asset and checks it against the manifest checksum
+// before returning it.
+func Download(ctx context.Context, a Asset) ([]byte, error) {
+	data, err := get(ctx, a.URL, 256<<20)
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(data, a); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func Verify(data []byte, a Asset) error {
+	sum := sha256.Sum256(data)
+	if a.SHA256 == "" || !strings.EqualFold(hex.EncodeToString(sum[:]), a.SHA256) {
+		return errors.New("downloaded binary does not match the release checksum")
+	}
+	return nil
+}
+
+func get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// Newer reports whether version a is newer than b. Versions are dotted
+// numbers with an optional leading "v"; anything after a "-" is ignored.
+func Newer(a, b string) bool {
+	pa, pb := parts(a), parts(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+func parts(v string) []int {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	var out []int
+	for _, p := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(p)
+		out = append(out, n)
+	}
+	return out
+}
+
+// PackageManager names the package manager that installed exe, if any.
+// Those installs should be updated through the package manager so it keeps
+// track of the version.
+func PackageManager(exe string) string {
+	p := filepath.ToSlash(strings.ToLower(exe))
+	switch {
+	case strings.Contains(p, "/cellar/") || strings.Contains(p, "/homebrew/"):
+		return "brew"
+	case strings.Contains(p, "/scoop/"):
+		return "scoop"
+	}
+	return ""
+}
+
+// Apply replaces the binary at exe with data. The new binary is written next
+// to the old one and moved into place so a failed write leaves the old one
+// working.
+func Apply(exe string, data []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(exe), "."+filepath.Base(exe)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()|0o111); err != nil {
+		return err
+	}
+	return replace(tmp.Name(), exe)
+}
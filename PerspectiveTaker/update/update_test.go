@@ -0,0 +1,100 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func signed(t *testing.T, key ed25519.PrivateKey, manifest []byte) []byte {
+	t.Helper()
+	body, err := json.Marshal(document{
+		Manifest:  base64.StdEncoding.EncodeToString(manifest),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestParse(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	_, other, _ := ed25519.GenerateKey(nil)
+	manifest := []byte(`{"version":"1.2.0","assets":{"linux-amd64":{"url":"https://example.com/pt","sha256":"ab"}}}`)
+
+	r, err := Parse(signed(t, priv, manifest), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != "1.2.0" || r.Assets["linux-amd64"].SHA256 != "ab" {
+		t.Errorf("got %+v", r)
+	}
+
+	if _, err := Parse(signed(t, other, manifest), pub); err == nil {
+		t.Error("manifest signed by another key was accepted")
+	}
+
+	// Relabelling a signed manifest with a newer version breaks the signature.
+	var doc document
+	json.Unmarshal(signed(t, priv, manifest), &doc)
+	doc.Manifest = base64.StdEncoding.EncodeToString([]byte(`{"version":"9.0.0","assets":{"linux-amd64":{"url":"https://example.com/pt","sha256":"ab"}}}`))
+	body, _ := json.Marshal(doc)
+	if _, err := Parse(body, pub); err == nil {
+		t.Error("manifest with a changed version was accepted")
+	}
+
+	if _, err := Parse(signed(t, priv, []byte(`{"assets":{}}`)), pub); err == nil {
+		t.Error("manifest without a version was accepted")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	data := []byte("binary")
+	sum := sha256.Sum256(data)
+	a := Asset{SHA256: hex.EncodeToString(sum[:])}
+	if err := Verify(data, a); err != nil {
+		t.Errorf("matching binary: %v", err)
+	}
+	if err := Verify([]byte("tampered"), a); err == nil {
+		t.Error("tampered binary was accepted")
+	}
+	if err := Verify(data, Asset{}); err == nil {
+		t.Error("asset without a checksum was accepted")
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1.2.0", "1.1.9", true},
+		{"v1.10", "1.9", true},
+		{"1.2", "1.2.0", false},
+		{"1.2.0", "1.2.1", false},
+		{"2.0.0-rc1", "1.9.9", true},
+		{"1.0.0", "1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPackageManager(t *testing.T) {
+	tests := map[string]string{
+		"/opt/homebrew/Cellar/perspective-taker/1.0/bin/perspective-taker":       "brew",
+		"C:/Users/me/scoop/apps/perspective-taker/current/perspective-taker.exe": "scoop",
+		"/usr/local/bin/perspective-taker":                                       "",
+	}
+	for exe, want := range tests {
+		if got := PackageManager(exe); got != want {
+			t.Errorf("PackageManager(%q) = %q, want %q", exe, got, want)
+		}
+	}
+}
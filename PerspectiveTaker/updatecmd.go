@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"perspective_taker/update"
+
+	"github.com/spf13/cobra"
+)
+
+// Set at release build time with -ldflags "-X main.version=... -X main.updatePublicKey=...".
+// updatePublicKey is the base64 ed25519 key that release manifests are signed with.
+var (
+	version         = "dev"
+	updatePublicKey = ""
+)
+
+func updateCmd() *cobra.Command {
+	var check bool
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Update perspective-taker to the latest release",
+		Args:  usageArgs(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdate(cmd, check)
+		},
+	}
+	cmd.Flags().BoolVar(&check, "check", false, "only report whether an update is available")
+	return cmd
+}
+
+var upgradeCommands = map[string]string{
+	"brew":  "brew upgrade perspective-taker",
+	"scoop": "scoop update perspective-taker",
+}
+
+type updateStatus struct {
+	Current   string `json:"current"`
+	Latest    string `json:"latest"`
+	Available bool   `json:"available"`
+	Updated   bool   `json:"updated"`
+}
+
+func runUpdate(cmd *cobra.Command, check bool) error {
+	if cfg.UpdateURL == "" {
+		return usageError{errors.New("no release endpoint configured; set update_url")}
+	}
+	key, err := base64.StdEncoding.DecodeString(updatePublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("this build has no release signing key, so releases cannot be verified; reinstall from a release build")
+	}
+	release, err := update.Check(cmd.Context(), cfg.UpdateURL, ed25519.PublicKey(key))
+	if err != nil {
+		return fmt.Errorf("checking for updates: %w", err)
+	}
+	status := updateStatus{
+		Current:   version,
+		Latest:    release.Version,
+		Available: version == "dev" || update.Newer(release.Version, version),
+	}
+	logger.Info("checked for updates", "current", version, "latest", release.Version)
+
+	if status.Available && !check {
+		if err := installUpdate(cmd, release); err != nil {
+			return err
+		}
+		status.Updated = true
+	}
+
+	if jsonOutput {
+		return printJSON(status)
+	}
+	switch {
+	case status.Updated:
+		fmt.Printf("Updated perspective-taker from %s to %s.\n", version, release.Version)
+	case status.Available:
+		fmt.Printf("perspective-taker %s is available (you have %s). Run 'perspective-taker update' to install it.\n", release.Version, version)
+	default:
+		fmt.Printf("perspective-taker %s is up to date.\n", version)
+	}
+	return nil
+}
+
+func installUpdate(cmd *cobra.Command, release update.Release) error {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return fmt.Errorf("locating the running binary: %w", err)
+	}
+	if pm := update.PackageManager(exe); pm != "" {
+		return fmt.Errorf("perspective-taker was installed with %s; update it with '%s'", pm, upgradeCommands[pm])
+	}
+
+	asset, ok := release.Assets[update.Platform()]
+	if !ok {
+		return fmt.Errorf("release %s has no binary for %s", release.Version, update.Platform())
+	}
+
+	fmt.Fprintf(ui(), "Downloading perspective-taker %s...\n", release.Version)
+	data, err := update.Download(cmd.Context(), asset)
+	if err != nil {
+		return fmt.Errorf("downloading update: %w", err)
+	}
+	if err := update.Apply(exe, data); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("cannot replace %s: %w; rerun with permission to write there or reinstall manually", exe, err)
+		}
+		return fmt.Errorf("installing update: %w", err)
+	}
+	return nil
+}